@@ -0,0 +1,284 @@
+// Package auth authenticates the callers of a service from the bearer token
+// in their request metadata and authorizes them per method.
+//
+// A token is either a JWT signed by a key of a local JSON Web Key Set, whose
+// "roles" claim lists the roles of its subject, or a static API key mapped to
+// a subject and roles by a file. The interceptors of this package reject
+// requests without valid token with Unauthenticated, and requests whose
+// caller has none of the roles the Policy requires for the method with
+// PermissionDenied:
+//
+//	authFlags := auth.ServerFlags()
+//	flag.Parse()
+//	a, err := authFlags.Authenticator()
+//	s := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(a.UnaryServerInterceptor(policy)),
+//		grpc.ChainStreamInterceptor(a.StreamServerInterceptor(policy)))
+//
+// Clients send their token with the PerRPCCredentials of ClientFlags.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"ecommerce/rpcerr"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// authorizationKey is the request metadata key carrying "Bearer <token>".
+const authorizationKey = "authorization"
+
+// Roles granted by tokens and required by the policies of the services.
+const (
+	RoleCatalogAdmin = "catalog-admin" // manages the products of the catalog
+	RoleWarehouse    = "warehouse"     // manages stock and ships orders
+	RoleOrderService = "order-service" // the order service, reserving stock
+)
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Subject string
+	Roles   []string
+}
+
+// HasRole reports whether p was granted any of roles.
+func (p *Principal) HasRole(roles ...string) bool {
+	for _, have := range p.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying p as the authenticated caller.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the caller authenticated by the interceptors, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Server holds the authentication settings of a server.
+type Server struct {
+	// JWKSFile is the JSON Web Key Set verifying JWTs.
+	JWKSFile string
+	// APIKeysFile maps static API keys to principals, see Authenticator.
+	APIKeysFile string
+	// Issuer and Audience, if set, must match the "iss" and "aud" JWT claims.
+	Issuer, Audience string
+}
+
+// ServerFlags registers the -auth-jwks, -auth-api-keys, -auth-issuer and
+// -auth-audience flags and returns the settings they fill in.
+func ServerFlags() *Server {
+	s := &Server{}
+	flag.StringVar(&s.JWKSFile, "auth-jwks", "", "JSON Web Key Set verifying bearer JWTs")
+	flag.StringVar(&s.APIKeysFile, "auth-api-keys", "", "JSON file of static API keys accepted as bearer tokens")
+	flag.StringVar(&s.Issuer, "auth-issuer", "", "required issuer (iss) of JWTs")
+	flag.StringVar(&s.Audience, "auth-audience", "", "required audience (aud) of JWTs")
+	return s
+}
+
+// Enabled reports whether authentication is configured.
+func (s *Server) Enabled() bool {
+	return s.JWKSFile != "" || s.APIKeysFile != ""
+}
+
+// apiKey is an entry of the API keys file:
+//
+//	{"keys": [{"key": "...", "subject": "inventory-bot", "roles": ["warehouse"]}]}
+type apiKey struct {
+	Key     string   `json:"key"`
+	Subject string   `json:"subject"`
+	Roles   []string `json:"roles"`
+}
+
+// Authenticator authenticates requests. A nil *Authenticator, returned when
+// authentication is not configured, lets every request through.
+type Authenticator struct {
+	keys             *KeySet
+	apiKeys          map[[sha256.Size]byte]*Principal // by key hash
+	issuer, audience string
+	now              func() time.Time
+}
+
+// Authenticator loads the configured keys. It returns nil if neither a key
+// set nor API keys are configured.
+func (s *Server) Authenticator() (*Authenticator, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+	a := &Authenticator{issuer: s.Issuer, audience: s.Audience, now: time.Now}
+	if s.JWKSFile != "" {
+		keys, err := LoadKeySet(s.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.keys = keys
+	}
+	if s.APIKeysFile != "" {
+		b, err := os.ReadFile(s.APIKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+		var file struct {
+			Keys []apiKey `json:"keys"`
+		}
+		if err := json.Unmarshal(b, &file); err != nil {
+			return nil, fmt.Errorf("auth: %v: %w", s.APIKeysFile, err)
+		}
+		a.apiKeys = make(map[[sha256.Size]byte]*Principal, len(file.Keys))
+		for _, k := range file.Keys {
+			if k.Key == "" || k.Subject == "" {
+				return nil, fmt.Errorf("auth: %v: API keys need a key and a subject", s.APIKeysFile)
+			}
+			a.apiKeys[sha256.Sum256([]byte(k.Key))] = &Principal{Subject: k.Subject, Roles: k.Roles}
+		}
+	}
+	return a, nil
+}
+
+// Authenticate returns the caller of the request of ctx. It fails with
+// Unauthenticated if the request has no valid bearer token.
+func (a *Authenticator) Authenticate(ctx context.Context) (*Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationKey)
+	if len(values) == 0 {
+		return nil, rpcerr.Unauthenticated("missing bearer token in %q metadata", authorizationKey)
+	}
+	scheme, token, _ := strings.Cut(values[0], " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, rpcerr.Unauthenticated("%q metadata must be \"Bearer <token>\"", authorizationKey)
+	}
+
+	// API keys are looked up by hash, which does not reveal through timing
+	// how much of a guessed key is right.
+	if p, found := a.apiKeys[sha256.Sum256([]byte(token))]; found {
+		return p, nil
+	}
+	if a.keys == nil || strings.Count(token, ".") != 2 {
+		return nil, rpcerr.Unauthenticated("invalid bearer token")
+	}
+	claims, err := a.keys.Verify(token, a.now())
+	if err != nil {
+		return nil, rpcerr.Unauthenticated("invalid bearer token: %v", err)
+	}
+	if err := a.checkClaims(claims); err != nil {
+		return nil, rpcerr.Unauthenticated("invalid bearer token: %v", err)
+	}
+	return &Principal{Subject: claims.Subject, Roles: claims.Roles}, nil
+}
+
+func (a *Authenticator) checkClaims(claims *Claims) error {
+	if claims.Subject == "" {
+		return errors.New("no subject")
+	}
+	if a.issuer != "" && claims.Issuer != a.issuer {
+		return fmt.Errorf("issuer %q is not accepted", claims.Issuer)
+	}
+	if a.audience != "" {
+		for _, aud := range claims.Audience {
+			if aud == a.audience {
+				return nil
+			}
+		}
+		return errors.New("token is meant for another audience")
+	}
+	return nil
+}
+
+// Policy maps the full name of every method of a service, such as
+// "/product.ProductInfo/addProduct", to the roles allowed to call it.
+// An empty list admits any authenticated caller.
+type Policy map[string][]string
+
+// NewPolicy returns the policy of service from its rules, keyed by method
+// name, e.g. "addProduct". It panics unless rules cover exactly the methods
+// of service, so that a method added later cannot go unprotected.
+func NewPolicy(service grpc.ServiceDesc, rules map[string][]string) Policy {
+	p := make(Policy, len(rules))
+	add := func(method string) {
+		roles, found := rules[method]
+		if !found {
+			panic(fmt.Sprintf("auth: no rule for %v/%v", service.ServiceName, method))
+		}
+		p["/"+service.ServiceName+"/"+method] = roles
+	}
+	for _, m := range service.Methods {
+		add(m.MethodName)
+	}
+	for _, s := range service.Streams {
+		add(s.StreamName)
+	}
+	for method := range rules {
+		if _, found := p["/"+service.ServiceName+"/"+method]; !found {
+			panic(fmt.Sprintf("auth: %v has no method %v", service.ServiceName, method))
+		}
+	}
+	return p
+}
+
+// authorize authenticates the request of ctx and checks that the caller may
+// call method, returning the context to serve the request with.
+func (a *Authenticator) authorize(ctx context.Context, policy Policy, method string) (context.Context, error) {
+	if a == nil {
+		return ctx, nil
+	}
+	p, err := a.Authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roles, found := policy[method]
+	if !found || (len(roles) > 0 && !p.HasRole(roles...)) {
+		return nil, rpcerr.PermissionDenied(p.Subject, method, roles)
+	}
+	return NewContext(ctx, p), nil
+}
+
+// UnaryServerInterceptor authorizes every unary call by policy.
+func (a *Authenticator) UnaryServerInterceptor(policy Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, policy, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authorizes every stream by policy.
+func (a *Authenticator) StreamServerInterceptor(policy Policy) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), policy, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// now is the time at which the tests verify tokens.
+var now = time.Unix(1700000000, 0)
+
+// testKeys are the signing keys of the key set of the tests.
+type testKeys struct {
+	ec  *ecdsa.PrivateKey // kid "ec"
+	rsa *rsa.PrivateKey   // kid "rsa"
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA", Kid: kid, Alg: "RS256", Use: "sig",
+		N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// writeJSON writes v to name in dir and returns the path of the file.
+func writeJSON(t *testing.T, dir, name string, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// newAuthenticator returns an authenticator requiring the issuer "test" and
+// the audience "orders", trusting the keys it returns and the API key
+// "secret" of the subject "bot".
+func newAuthenticator(t *testing.T) (*Authenticator, testKeys) {
+	t.Helper()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	s := &Server{
+		JWKSFile: writeJSON(t, dir, "jwks.json", map[string][]JWK{"keys": {
+			ECJWK("ec", &ecKey.PublicKey), rsaJWK("rsa", &rsaKey.PublicKey),
+		}}),
+		APIKeysFile: writeJSON(t, dir, "keys.json", map[string][]apiKey{"keys": {
+			{Key: "secret", Subject: "bot", Roles: []string{RoleWarehouse}},
+		}}),
+		Issuer:   "test",
+		Audience: "orders",
+	}
+	a, err := s.Authenticator()
+	if err != nil {
+		t.Fatalf("Authenticator: %v", err)
+	}
+	a.now = func() time.Time { return now }
+	return a, testKeys{ec: ecKey, rsa: rsaKey}
+}
+
+// validClaims returns claims the authenticator of newAuthenticator accepts.
+func validClaims() *Claims {
+	return &Claims{
+		Subject:   "alice",
+		Issuer:    "test",
+		Audience:  audience{"orders"},
+		ExpiresAt: now.Add(time.Hour).Unix(),
+		Roles:     []string{RoleWarehouse},
+	}
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// signRS256 returns claims as an RS256 token signed by key, whose JWK has id kid.
+func signRS256(t *testing.T, claims *Claims, kid string, key *rsa.PrivateKey) string {
+	t.Helper()
+	signed := encodeSegment(t, header{Alg: "RS256", Kid: kid, Typ: "JWT"}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func signES256(t *testing.T, claims *Claims, kid string, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	token, err := Sign(claims, kid, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// withHeader replaces the header of token by h, keeping its claims and signature.
+func withHeader(t *testing.T, token string, h header) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	return encodeSegment(t, h) + "." + parts[1] + "." + parts[2]
+}
+
+// withSignature replaces the signature of token by sig.
+func withSignature(token string, sig []byte) string {
+	return token[:strings.LastIndex(token, ".")+1] + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// authenticate authenticates a request carrying the authorization metadata
+// value, if not empty.
+func authenticate(a *Authenticator, value string) (*Principal, error) {
+	md := metadata.MD{}
+	if value != "" {
+		md.Set(authorizationKey, value)
+	}
+	return a.Authenticate(metadata.NewIncomingContext(context.Background(), md))
+}
+
+func TestAuthenticate(t *testing.T) {
+	a, keys := newAuthenticator(t)
+	claims := func(edit func(c *Claims)) *Claims {
+		c := validClaims()
+		edit(c)
+		return c
+	}
+	valid := signES256(t, validClaims(), "ec", keys.ec)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string // authorization metadata
+		subject string // "" if the request is to be rejected
+	}{
+		{"ES256", "Bearer " + valid, "alice"},
+		{"RS256", "Bearer " + signRS256(t, validClaims(), "rsa", keys.rsa), "alice"},
+		{"lower case scheme", "bearer " + valid, "alice"},
+		{"API key", "Bearer secret", "bot"},
+		{"expired within leeway", "Bearer " + signES256(t, claims(func(c *Claims) { c.ExpiresAt = now.Add(-30 * time.Second).Unix() }), "ec", keys.ec), "alice"},
+		{"audience list", "Bearer " + signES256(t, claims(func(c *Claims) { c.Audience = audience{"billing", "orders"} }), "ec", keys.ec), "alice"},
+
+		{"no metadata", "", ""},
+		{"basic scheme", "Basic " + valid, ""},
+		{"no token", "Bearer ", ""},
+		{"bad API key", "Bearer secreT", ""},
+		{"garbage", "Bearer a.b.c", ""},
+		{"expired", "Bearer " + signES256(t, claims(func(c *Claims) { c.ExpiresAt = now.Add(-2 * time.Minute).Unix() }), "ec", keys.ec), ""},
+		{"no expiry", "Bearer " + signES256(t, claims(func(c *Claims) { c.ExpiresAt = 0 }), "ec", keys.ec), ""},
+		{"not valid yet", "Bearer " + signES256(t, claims(func(c *Claims) { c.NotBefore = now.Add(2 * time.Minute).Unix() }), "ec", keys.ec), ""},
+		{"no subject", "Bearer " + signES256(t, claims(func(c *Claims) { c.Subject = "" }), "ec", keys.ec), ""},
+		{"wrong issuer", "Bearer " + signES256(t, claims(func(c *Claims) { c.Issuer = "evil" }), "ec", keys.ec), ""},
+		{"no issuer", "Bearer " + signES256(t, claims(func(c *Claims) { c.Issuer = "" }), "ec", keys.ec), ""},
+		{"wrong audience", "Bearer " + signES256(t, claims(func(c *Claims) { c.Audience = audience{"billing"} }), "ec", keys.ec), ""},
+		{"no audience", "Bearer " + signES256(t, claims(func(c *Claims) { c.Audience = nil }), "ec", keys.ec), ""},
+		{"unknown kid", "Bearer " + signES256(t, validClaims(), "other", keys.ec), ""},
+		{"untrusted key", "Bearer " + signES256(t, validClaims(), "ec", otherKey), ""},
+		{"RS256 alg on EC key", "Bearer " + withHeader(t, valid, header{Alg: "RS256", Kid: "ec"}), ""},
+		{"ES256 alg on RSA key", "Bearer " + signES256(t, validClaims(), "rsa", keys.ec), ""},
+		{"HS256 alg", "Bearer " + withHeader(t, valid, header{Alg: "HS256", Kid: "ec"}), ""},
+		{"none alg", "Bearer " + withSignature(withHeader(t, valid, header{Alg: "none", Kid: "ec"}), nil), ""},
+		{"no signature", "Bearer " + withSignature(valid, nil), ""},
+		{"short ES256 signature", "Bearer " + withSignature(valid, make([]byte, 63)), ""},
+		{"long ES256 signature", "Bearer " + withSignature(valid, make([]byte, 65)), ""},
+		{"tampered claims", "Bearer " + strings.Replace(valid, strings.Split(valid, ".")[1], encodeSegment(t, claims(func(c *Claims) { c.Subject = "mallory" })), 1), ""},
+	}
+	for _, test := range tests {
+		p, err := authenticate(a, test.value)
+		if test.subject == "" {
+			if status.Code(err) != codes.Unauthenticated {
+				t.Errorf("%v: Authenticate = %v, %v, want Unauthenticated", test.name, p, err)
+			}
+			continue
+		}
+		if err != nil || p.Subject != test.subject {
+			t.Errorf("%v: Authenticate = %v, %v, want subject %v", test.name, p, err, test.subject)
+		}
+	}
+}
+
+func TestLoadKeySetRejectsWeakRSAKeys(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strong, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withExponent := func(e int64) JWK {
+		k := rsaJWK("rsa", &strong.PublicKey)
+		k.E = base64.RawURLEncoding.EncodeToString(big.NewInt(e).Bytes())
+		return k
+	}
+	tests := []struct {
+		name string
+		key  JWK
+	}{
+		{"1024 bits", rsaJWK("rsa", &small.PublicKey)},
+		{"exponent 1", withExponent(1)},
+		{"even exponent", withExponent(65536)},
+		{"exponent over 32 bits", withExponent(1<<32 + 1)},
+	}
+	dir := t.TempDir()
+	for _, test := range tests {
+		file := writeJSON(t, dir, "jwks.json", map[string][]JWK{"keys": {test.key}})
+		if _, err := LoadKeySet(file); err == nil {
+			t.Errorf("%v: LoadKeySet accepted the key", test.name)
+		}
+	}
+	file := writeJSON(t, dir, "jwks.json", map[string][]JWK{"keys": {rsaJWK("rsa", &strong.PublicKey)}})
+	if _, err := LoadKeySet(file); err != nil {
+		t.Errorf("LoadKeySet of a 2048 bit key: %v", err)
+	}
+}
+
+func TestNilAuthenticatorAdmitsEveryCall(t *testing.T) {
+	a, err := (&Server{}).Authenticator()
+	if a != nil || err != nil {
+		t.Fatalf("Authenticator without keys = %v, %v, want nil", a, err)
+	}
+	if _, err := a.authorize(context.Background(), Policy{}, "/s/m"); err != nil {
+		t.Errorf("authorize = %v, want nil", err)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	a, keys := newAuthenticator(t)
+	policy := Policy{"/s/open": nil, "/s/restricted": {RoleCatalogAdmin, RoleWarehouse}}
+	customer := signES256(t, &Claims{Subject: "bob", Issuer: "test", Audience: audience{"orders"}, ExpiresAt: now.Add(time.Hour).Unix()}, "ec", keys.ec)
+	warehouse := signES256(t, validClaims(), "ec", keys.ec)
+	tests := []struct {
+		token, method string
+		code          codes.Code
+	}{
+		{"", "/s/open", codes.Unauthenticated},
+		{customer, "/s/open", codes.OK},
+		{customer, "/s/restricted", codes.PermissionDenied},
+		{warehouse, "/s/restricted", codes.OK},
+		{warehouse, "/s/unknown", codes.PermissionDenied},
+	}
+	for _, test := range tests {
+		md := metadata.MD{}
+		if test.token != "" {
+			md.Set(authorizationKey, "Bearer "+test.token)
+		}
+		ctx, err := a.authorize(metadata.NewIncomingContext(context.Background(), md), policy, test.method)
+		if status.Code(err) != test.code {
+			t.Errorf("%v: authorize = %v, want %v", test.method, err, test.code)
+			continue
+		}
+		if err == nil {
+			if p, ok := FromContext(ctx); !ok || p.Subject == "" {
+				t.Errorf("%v: no principal in the context", test.method)
+			}
+		}
+	}
+}
+
+func TestClientToken(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "token")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ECOMMERCE_TEST_TOKEN", "from-env")
+	tests := []struct {
+		name   string
+		client Client
+		want   string // "" if no token is sent
+	}{
+		{"flag first", Client{Token: "from-flag", TokenFile: file, TokenEnv: "ECOMMERCE_TEST_TOKEN"}, "from-flag"},
+		{"file before environment", Client{TokenFile: file, TokenEnv: "ECOMMERCE_TEST_TOKEN"}, "from-file"},
+		{"environment", Client{TokenEnv: "ECOMMERCE_TEST_TOKEN"}, "from-env"},
+		{"unset environment", Client{TokenEnv: "ECOMMERCE_UNSET_TOKEN"}, ""},
+		{"none", Client{}, ""},
+	}
+	for _, test := range tests {
+		opts, err := test.client.DialOptions(false)
+		if err != nil {
+			t.Errorf("%v: DialOptions: %v", test.name, err)
+			continue
+		}
+		if got, _ := test.client.token(); got != test.want || (len(opts) == 0) != (test.want == "") {
+			t.Errorf("%v: token %q with %d dial options, want %q", test.name, got, len(opts), test.want)
+		}
+	}
+
+	// A token file that cannot be read, or is empty, is an error rather than
+	// calls going out without a token.
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{filepath.Join(dir, "missing"), empty} {
+		if _, err := (&Client{TokenFile: file}).DialOptions(false); err == nil {
+			t.Errorf("DialOptions with token file %v succeeded", filepath.Base(file))
+		}
+	}
+}
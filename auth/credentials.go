@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Client holds the token a client sends with every call.
+type Client struct {
+	// Token is a JWT or an API key. No token is sent when it is empty, and
+	// none of the sources below is set.
+	Token string
+	// TokenFile, used when Token is empty, holds the token.
+	TokenFile string
+	// TokenEnv, used when Token and TokenFile are empty, names the
+	// environment variable holding the token.
+	TokenEnv string
+}
+
+// ClientFlags registers the -<prefix>token and -<prefix>token-file flags and
+// returns the setting they fill in, falling back to the environment variable
+// ECOMMERCE_<PREFIX>TOKEN, e.g. ECOMMERCE_DEPENDENCY_TOKEN for the prefix
+// "dependency-". Prefer the file or the variable: the command line of a
+// process is visible to every local user.
+func ClientFlags(prefix string) *Client {
+	c := &Client{TokenEnv: "ECOMMERCE_" + strings.ToUpper(strings.ReplaceAll(prefix, "-", "_")) + "TOKEN"}
+	flag.StringVar(&c.Token, prefix+"token", "", "bearer token (JWT or API key) sent with every call; prefer -"+prefix+"token-file or $"+c.TokenEnv)
+	flag.StringVar(&c.TokenFile, prefix+"token-file", "", "file holding the bearer token sent with every call")
+	return c
+}
+
+// token returns the token to send, "" if there is none.
+func (c *Client) token() (string, error) {
+	switch {
+	case c.Token != "":
+		return c.Token, nil
+	case c.TokenFile != "":
+		b, err := os.ReadFile(c.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("auth: %w", err)
+		}
+		token := strings.TrimSpace(string(b))
+		if token == "" {
+			return "", fmt.Errorf("auth: %v: no token", c.TokenFile)
+		}
+		return token, nil
+	case c.TokenEnv != "":
+		return strings.TrimSpace(os.Getenv(c.TokenEnv)), nil
+	}
+	return "", nil
+}
+
+// DialOptions returns the options sending the token with every call, none
+// if there is no token. Over TLS, set secure, so that the token is never
+// sent in plaintext by mistake.
+func (c *Client) DialOptions(secure bool) ([]grpc.DialOption, error) {
+	token, err := c.token()
+	if err != nil || token == "" {
+		return nil, err
+	}
+	return []grpc.DialOption{grpc.WithPerRPCCredentials(bearerToken{token: token, secure: secure})}, nil
+}
+
+// bearerToken is the credentials.PerRPCCredentials sending a bearer token.
+type bearerToken struct {
+	token  string
+	secure bool
+}
+
+var _ credentials.PerRPCCredentials = bearerToken{}
+
+func (t bearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authorizationKey: "Bearer " + t.token}, nil
+}
+
+func (t bearerToken) RequireTransportSecurity() bool {
+	return t.secure
+}
@@ -0,0 +1,139 @@
+// Command gencerts writes a development CA and the certificates signed by it
+// into a directory, ssl/ by default:
+//
+//	ca.crt, ca.key          the CA, to verify servers (tls-ca) and clients (tls-client-ca) with
+//	server.crt, server.key  a server certificate for the -hosts names (tls-cert, tls-key)
+//	client.crt, client.key  a client certificate for mutual TLS (tls-cert, tls-key)
+//
+// The certificates are for local development only. Existing files are
+// overwritten.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const tag = "[Certs]"
+
+var (
+	dir      = flag.String("dir", "ssl", "directory to write the certificates and keys to")
+	hosts    = flag.String("hosts", "localhost,127.0.0.1,::1", "comma separated DNS names and IP addresses of the server certificate")
+	validFor = flag.Duration("valid-for", 365*24*time.Hour, "validity period of the certificates")
+)
+
+// issued is a certificate with its key.
+type issued struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func main() {
+	flag.Parse()
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Fatalf("%v %v\n", tag, err)
+	}
+
+	ca, err := issue("ecommerce dev CA", nil, func(t *x509.Certificate) {
+		t.IsCA = true
+		t.BasicConstraintsValid = true
+		t.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	})
+	if err != nil {
+		log.Fatalf("%v CA: %v\n", tag, err)
+	}
+	server, err := issue("ecommerce server", ca, func(t *x509.Certificate) {
+		t.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		for _, h := range strings.Split(*hosts, ",") {
+			if h = strings.TrimSpace(h); h == "" {
+				continue
+			}
+			if ip := net.ParseIP(h); ip != nil {
+				t.IPAddresses = append(t.IPAddresses, ip)
+			} else {
+				t.DNSNames = append(t.DNSNames, h)
+			}
+		}
+	})
+	if err != nil {
+		log.Fatalf("%v server: %v\n", tag, err)
+	}
+	client, err := issue("ecommerce client", ca, func(t *x509.Certificate) {
+		t.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	})
+	if err != nil {
+		log.Fatalf("%v client: %v\n", tag, err)
+	}
+
+	for _, out := range []struct {
+		name string
+		c    *issued
+	}{{"ca", ca}, {"server", server}, {"client", client}} {
+		if err := write(out.name, out.c); err != nil {
+			log.Fatalf("%v %v: %v\n", tag, out.name, err)
+		}
+		log.Printf("%v Wrote %v\n", tag, filepath.Join(*dir, out.name+".crt"))
+	}
+}
+
+// issue creates a certificate for commonName signed by parent, or self
+// signed if parent is nil, after customize has filled in its usage.
+func issue(commonName string, parent *issued, customize func(t *x509.Certificate)) (*issued, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"ecommerce"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(*validFor),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	customize(template)
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &issued{cert: cert, key: key}, nil
+}
+
+// write stores c as <name>.crt and its key, readable by the owner only, as <name>.key.
+func write(name string, c *issued) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(c.key)
+	if err != nil {
+		return err
+	}
+	crt := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	if err := os.WriteFile(filepath.Join(*dir, name+".crt"), crt, 0o644); err != nil {
+		return err
+	}
+	key := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return os.WriteFile(filepath.Join(*dir, name+".key"), key, 0o600)
+}
@@ -0,0 +1,125 @@
+// Command mintoken signs development JWTs for the services. On first use it
+// creates a signing key and the key set verifying it in a directory, ssl/ by
+// default:
+//
+//	jwt.key     the ES256 signing key, readable by the owner only
+//	jwks.json   the JSON Web Key Set to start the services with (auth-jwks)
+//
+// and then prints a token for -sub granted -roles, to pass as -token:
+//
+//	go run ./cmd/mintoken -sub alice -roles catalog-admin,warehouse
+//
+// The keys are for local development only.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ecommerce/auth"
+)
+
+const tag = "[Token]"
+
+var (
+	dir      = flag.String("dir", "ssl", "directory holding jwt.key and jwks.json, created on first use")
+	kid      = flag.String("kid", "dev", "key id of the signing key")
+	subject  = flag.String("sub", "dev", "subject (sub) of the token")
+	roles    = flag.String("roles", "", "comma separated roles granted by the token")
+	issuer   = flag.String("issuer", "", "issuer (iss) of the token")
+	audience = flag.String("audience", "", "audience (aud) of the token")
+	ttl      = flag.Duration("ttl", 24*time.Hour, "validity period of the token")
+)
+
+func main() {
+	flag.Parse()
+	key, err := signingKey()
+	if err != nil {
+		log.Fatalf("%v %v\n", tag, err)
+	}
+
+	now := time.Now()
+	claims := &auth.Claims{
+		Subject:   *subject,
+		Issuer:    *issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(*ttl).Unix(),
+	}
+	if *audience != "" {
+		claims.Audience = []string{*audience}
+	}
+	for _, r := range strings.Split(*roles, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			claims.Roles = append(claims.Roles, r)
+		}
+	}
+	token, err := auth.Sign(claims, *kid, key)
+	if err != nil {
+		log.Fatalf("%v %v\n", tag, err)
+	}
+	fmt.Println(token)
+}
+
+// signingKey reads jwt.key, or creates it along with jwks.json if it does
+// not exist yet.
+func signingKey() (*ecdsa.PrivateKey, error) {
+	keyFile := filepath.Join(*dir, "jwt.key")
+	b, err := os.ReadFile(keyFile)
+	if err == nil {
+		block, _ := pem.Decode(b)
+		if block == nil {
+			return nil, fmt.Errorf("%v: no PEM data", keyFile)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", keyFile, err)
+		}
+		ec, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%v: not an EC key", keyFile)
+		}
+		return ec, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	jwks, err := json.MarshalIndent(struct {
+		Keys []auth.JWK `json:"keys"`
+	}{[]auth.JWK{auth.ECJWK(*kid, &key.PublicKey)}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	jwksFile := filepath.Join(*dir, "jwks.json")
+	if err := os.WriteFile(jwksFile, append(jwks, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return nil, err
+	}
+	log.Printf("%v Wrote %v and %v\n", tag, keyFile, jwksFile)
+	return key, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+
+	"ecommerce/auth"
+	pb "ecommerce/inventory/proto"
+	"ecommerce/rpcerr"
+	"ecommerce/tlsconfig"
+)
+
+const (
+	addr      = "localhost:50083"
+	tag       = "[Client]"
+	productId = "demo-product"
+)
+
+var (
+	clientTLS  = tlsconfig.ClientFlags("")
+	clientAuth = auth.ClientFlags("")
+)
+
+func main() {
+	flag.Parse()
+	creds, err := clientTLS.Credentials()
+	if err != nil {
+		log.Fatalf("%vFailed to load TLS credentials: %v\n", tag, err)
+	}
+	authOpts, err := clientAuth.DialOptions(clientTLS.Enabled())
+	if err != nil {
+		log.Fatalf("%vFailed to load the token: %v\n", tag, err)
+	}
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, authOpts...)
+	con, err := grpc.Dial(addr, opts...)
+
+	if err != nil {
+		log.Fatalf("%vFailed to connect: %v\n", tag, err)
+	}
+
+	// close connection with error handling
+	defer func(con *grpc.ClientConn) {
+		err := con.Close()
+		if err != nil {
+			log.Fatalf("%v[Close] [Error]: %v\n", tag, err)
+		}
+	}(con)
+
+	c := pb.NewInventoryClient(con)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	level, err := c.SetStock(ctx, &pb.SetStockRequest{ProductId: productId, OnHand: 5})
+	if err != nil {
+		log.Fatalf("%v [S] [Error] %v\n\n", tag, rpcerr.Describe(err))
+	}
+	log.Printf("%v [S] [Success] %v \n", tag, level)
+
+	// Reservation ids are picked by the caller, fresh ones for every run.
+	first, second := uuid.NewString(), uuid.NewString()
+
+	r, err := c.Reserve(ctx, &pb.ReserveRequest{ReservationId: first, Items: []*pb.ReservedItem{{ProductId: productId, Quantity: 3}}})
+	if err != nil {
+		log.Fatalf("%v [Reserve] [Error] %v\n\n", tag, rpcerr.Describe(err))
+	}
+	log.Printf("%v [Reserve] [Success] %v \n", tag, r)
+
+	// Only 2 units are left.
+	_, err = c.Reserve(ctx, &pb.ReserveRequest{ReservationId: second, Items: []*pb.ReservedItem{{ProductId: productId, Quantity: 3}}})
+	log.Printf("%v [Reserve] [Out Of Stock] %v \n", tag, rpcerr.Describe(err))
+
+	if r, err = c.Commit(ctx, &pb.ReservationId{Id: first}); err != nil {
+		log.Fatalf("%v [Commit] [Error] %v\n\n", tag, rpcerr.Describe(err))
+	}
+	log.Printf("%v [Commit] [Success] %v \n", tag, r)
+
+	_, err = c.Release(ctx, &pb.ReservationId{Id: first})
+	log.Printf("%v [Release] [Committed] %v \n", tag, rpcerr.Describe(err))
+
+	if r, err = c.Reserve(ctx, &pb.ReserveRequest{ReservationId: second, Items: []*pb.ReservedItem{{ProductId: productId, Quantity: 2}}}); err != nil {
+		log.Fatalf("%v [Reserve] [Error] %v\n\n", tag, rpcerr.Describe(err))
+	}
+	log.Printf("%v [Reserve] [Success] %v \n", tag, r)
+	if r, err = c.Release(ctx, &pb.ReservationId{Id: second}); err != nil {
+		log.Fatalf("%v [Release] [Error] %v\n\n", tag, rpcerr.Describe(err))
+	}
+	log.Printf("%v [Release] [Success] %v \n", tag, r)
+
+	if level, err = c.GetStock(ctx, &pb.ProductId{Id: productId}); err != nil {
+		log.Fatalf("%v [G] [Error] %v\n\n", tag, rpcerr.Describe(err))
+	}
+	log.Printf("%v [G] [Success] %v \n", tag, level)
+}
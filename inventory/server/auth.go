@@ -0,0 +1,16 @@
+package server
+
+import (
+	"ecommerce/auth"
+	pb "ecommerce/inventory/proto"
+)
+
+// Policy lets any authenticated caller read stock levels, the
+// warehouse set them, and the order service, or the warehouse, hold stock.
+var Policy = auth.NewPolicy(pb.Inventory_ServiceDesc, map[string][]string{
+	"setStock": {auth.RoleWarehouse},
+	"getStock": nil,
+	"reserve":  {auth.RoleOrderService, auth.RoleWarehouse},
+	"commit":   {auth.RoleOrderService, auth.RoleWarehouse},
+	"release":  {auth.RoleOrderService, auth.RoleWarehouse},
+})
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	pb "ecommerce/inventory/proto"
+	"ecommerce/rpcerr"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Reserve holds the requested units of every product, or of none if any
+// product has fewer units available than requested.
+func (s *Server) Reserve(ctx context.Context, in *pb.ReserveRequest) (*pb.Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, exists := s.reservations[in.ReservationId]; exists {
+		// A retry of a reservation that was already made.
+		return existing, status.New(codes.OK, "").Err()
+	}
+
+	// The same product may be listed more than once.
+	var items []*pb.ReservedItem
+	quantities := make(map[string]*pb.ReservedItem)
+	for _, item := range in.Items {
+		if merged, ok := quantities[item.ProductId]; ok {
+			merged.Quantity += item.Quantity
+			continue
+		}
+		merged := &pb.ReservedItem{ProductId: item.ProductId, Quantity: item.Quantity}
+		quantities[item.ProductId] = merged
+		items = append(items, merged)
+	}
+
+	levels := make([]*pb.StockLevel, 0, len(items))
+	var shortages []*errdetails.PreconditionFailure_Violation
+	for _, item := range items {
+		current := s.stock[item.ProductId]
+		if available := current.GetOnHand() - current.GetReserved(); available < item.Quantity {
+			shortages = append(shortages, &errdetails.PreconditionFailure_Violation{
+				Type:        rpcerr.ReasonOutOfStock,
+				Subject:     stockResource + "/" + item.ProductId,
+				Description: fmt.Sprintf("%v units of product %v requested, %v available", item.Quantity, item.ProductId, available),
+			})
+			continue
+		}
+		levels = append(levels, newStockLevel(item.ProductId, current.GetOnHand(), current.GetReserved()+item.Quantity))
+	}
+	if len(shortages) > 0 {
+		return nil, rpcerr.PreconditionFailed(rpcerr.ReasonOutOfStock, nil,
+			fmt.Sprintf("%v of %v product(s) out of stock", len(shortages), len(items)), shortages...)
+	}
+
+	r := &pb.Reservation{Id: in.ReservationId, Items: items, State: pb.ReservationState_RESERVATION_STATE_RESERVED}
+	if err := s.save(levels, r); err != nil {
+		return nil, rpcerr.Internal("[Error] Saving reservation %v: %v", r.Id, err)
+	}
+
+	return r, status.New(codes.OK, "").Err()
+}
+
+// Commit takes the units of a reservation out of the warehouse.
+func (s *Server) Commit(ctx context.Context, in *pb.ReservationId) (*pb.Reservation, error) {
+	return s.settle(in.Id, pb.ReservationState_RESERVATION_STATE_COMMITTED)
+}
+
+// Release makes the units of a reservation available again.
+func (s *Server) Release(ctx context.Context, in *pb.ReservationId) (*pb.Reservation, error) {
+	return s.settle(in.Id, pb.ReservationState_RESERVATION_STATE_RELEASED)
+}
+
+// settle moves a reserved reservation to the final state to, updating the
+// stock of its products. Settling it again in the same state is a no-op.
+func (s *Server) settle(id string, to pb.ReservationState) (*pb.Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.reservations[id]
+	if !exists {
+		return nil, rpcerr.NotFound(reservationResource, id)
+	}
+	if current.State == to {
+		return current, status.New(codes.OK, "").Err()
+	}
+	if current.State != pb.ReservationState_RESERVATION_STATE_RESERVED {
+		return nil, rpcerr.FailedPrecondition(rpcerr.ReasonInvalidTransition, reservationResource, id,
+			map[string]string{"from": current.State.String(), "to": to.String()},
+			"Reservation %v is %v and cannot become %v", id, current.State, to)
+	}
+
+	levels := make([]*pb.StockLevel, len(current.Items))
+	for i, item := range current.Items {
+		level := s.stock[item.ProductId]
+		onHand := level.GetOnHand()
+		if to == pb.ReservationState_RESERVATION_STATE_COMMITTED {
+			onHand -= item.Quantity
+		}
+		levels[i] = newStockLevel(item.ProductId, onHand, level.GetReserved()-item.Quantity)
+	}
+	settled := &pb.Reservation{Id: current.Id, Items: current.Items, State: to}
+	if err := s.save(levels, settled); err != nil {
+		return nil, rpcerr.Internal("[Error] Saving reservation %v: %v", id, err)
+	}
+
+	return settled, status.New(codes.OK, "").Err()
+}
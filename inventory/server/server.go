@@ -0,0 +1,45 @@
+// Package server implements the Inventory service, keeping stock levels and
+// reservations in memory and, once loaded from one, in a durable store. The
+// inventory service command serves it; tests start it in process.
+package server
+
+import (
+	"sync"
+
+	pb "ecommerce/inventory/proto"
+	"ecommerce/storage"
+)
+
+// Resource types reported in error details.
+var (
+	stockResource       = string((&pb.StockLevel{}).ProtoReflect().Descriptor().FullName())
+	reservationResource = string((&pb.Reservation{}).ProtoReflect().Descriptor().FullName())
+)
+
+// Server is the Inventory service. Its requests are expected to have been
+// checked against NewValidator, and its callers authorized by Policy.
+type Server struct {
+	pb.InventoryServer
+	// mu guards stock and reservations; writers hold it across the durable
+	// write so the log and the maps agree on the order of updates.
+	mu           sync.RWMutex
+	stock        map[string]*pb.StockLevel
+	reservations map[string]*pb.Reservation
+	db           *storage.DB
+}
+
+// New returns a server without stock, kept in memory only until Load is
+// called.
+func New() *Server {
+	return &Server{
+		stock:        make(map[string]*pb.StockLevel),
+		reservations: make(map[string]*pb.Reservation),
+	}
+}
+
+// Len returns the number of stock levels and reservations held.
+func (s *Server) Len() (levels, reservations int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.stock), len(s.reservations)
+}
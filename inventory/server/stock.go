@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+
+	pb "ecommerce/inventory/proto"
+	"ecommerce/rpcerr"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newStockLevel returns the level of productId, deriving available.
+func newStockLevel(productId string, onHand, reserved int64) *pb.StockLevel {
+	return &pb.StockLevel{ProductId: productId, OnHand: onHand, Reserved: reserved, Available: onHand - reserved}
+}
+
+func (s *Server) SetStock(ctx context.Context, in *pb.SetStockRequest) (*pb.StockLevel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reserved := s.stock[in.ProductId].GetReserved()
+	if in.OnHand < reserved {
+		return nil, rpcerr.FailedPrecondition(rpcerr.ReasonStockReserved, stockResource, in.ProductId, nil,
+			"Product %v has %v units reserved, more than the %v on hand requested", in.ProductId, reserved, in.OnHand)
+	}
+	level := newStockLevel(in.ProductId, in.OnHand, reserved)
+	if err := s.save([]*pb.StockLevel{level}, nil); err != nil {
+		return nil, rpcerr.Internal("[Error] Saving stock of %v: %v", in.ProductId, err)
+	}
+
+	return level, status.New(codes.OK, "").Err()
+}
+
+// GetStock returns the stock of a product. Products never stocked have none.
+func (s *Server) GetStock(ctx context.Context, in *pb.ProductId) (*pb.StockLevel, error) {
+	s.mu.RLock()
+	level, exists := s.stock[in.Id]
+	s.mu.RUnlock()
+
+	if !exists {
+		level = newStockLevel(in.Id, 0, 0)
+	}
+	return level, status.New(codes.OK, "").Err()
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	pb "ecommerce/inventory/proto"
+	"ecommerce/storage"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Keys of the durable store are prefixed by the kind of record they hold.
+const (
+	stockPrefix       = "stock/"
+	reservationPrefix = "reservation/"
+)
+
+// Load fills stock and reservations from the durable store.
+func (s *Server) Load(db *storage.DB) error {
+	var err error
+	db.Range(func(key string, value []byte) bool {
+		switch {
+		case strings.HasPrefix(key, stockPrefix):
+			level := &pb.StockLevel{}
+			if err = proto.Unmarshal(value, level); err != nil {
+				return false
+			}
+			s.stock[level.ProductId] = level
+		case strings.HasPrefix(key, reservationPrefix):
+			r := &pb.Reservation{}
+			if err = proto.Unmarshal(value, r); err != nil {
+				return false
+			}
+			s.reservations[r.Id] = r
+		default:
+			err = fmt.Errorf("unexpected key %q", key)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	s.db = db
+	return nil
+}
+
+// save atomically fsyncs levels and, if not nil, r to the durable store,
+// if one is configured, then applies them to the in-memory maps.
+// The caller holds mu.
+func (s *Server) save(levels []*pb.StockLevel, r *pb.Reservation) error {
+	if s.db != nil {
+		batch := &storage.Batch{}
+		for _, level := range levels {
+			b, err := proto.Marshal(level)
+			if err != nil {
+				return err
+			}
+			batch.Put(stockPrefix+level.ProductId, b)
+		}
+		if r != nil {
+			b, err := proto.Marshal(r)
+			if err != nil {
+				return err
+			}
+			batch.Put(reservationPrefix+r.Id, b)
+		}
+		if err := s.db.Write(batch); err != nil {
+			return err
+		}
+	}
+
+	for _, level := range levels {
+		s.stock[level.ProductId] = level
+	}
+	if r != nil {
+		s.reservations[r.Id] = r
+	}
+	return nil
+}
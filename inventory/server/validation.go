@@ -0,0 +1,23 @@
+package server
+
+import (
+	pb "ecommerce/inventory/proto"
+	"ecommerce/validation"
+)
+
+// NewValidator returns the input rules of the Inventory service.
+func NewValidator() *validation.Registry {
+	v := validation.NewRegistry()
+	v.Register(&pb.SetStockRequest{}, validation.Required("product_id"), validation.NonNegative("on_hand"))
+	v.Register(&pb.ProductId{}, validation.Required("id"))
+	v.Register(&pb.ReserveRequest{},
+		validation.Required("reservation_id"),
+		validation.MinItems("items", 1),
+		validation.Each("items",
+			validation.Required("product_id"),
+			validation.Positive("quantity"),
+		),
+	)
+	v.Register(&pb.ReservationId{}, validation.Required("id"))
+	return v
+}
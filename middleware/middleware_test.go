@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMain(m *testing.M) {
+	// The interceptors log every call, and recovered panics with their stack.
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// fakeStream is a grpc.ServerStream receiving n messages.
+type fakeStream struct {
+	grpc.ServerStream
+	n int
+}
+
+func (s *fakeStream) Context() context.Context { return context.Background() }
+
+func (s *fakeStream) RecvMsg(interface{}) error {
+	if s.n == 0 {
+		return io.EOF
+	}
+	s.n--
+	return nil
+}
+
+func (s *fakeStream) SendMsg(interface{}) error { return nil }
+
+func TestObserverCountsCalls(t *testing.T) {
+	o := NewObserver("[Test]")
+	unary := o.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/s/unary"}
+	for _, err := range []error{nil, nil, status.Error(codes.NotFound, "no")} {
+		unary(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) { return nil, err })
+	}
+	stream := o.StreamServerInterceptor()
+	stream(nil, &fakeStream{n: 2}, &grpc.StreamServerInfo{FullMethod: "/s/stream"}, func(_ interface{}, ss grpc.ServerStream) error {
+		for ss.RecvMsg(nil) == nil {
+			ss.SendMsg(nil)
+		}
+		return nil
+	})
+
+	var metrics map[string]methodStats
+	if err := json.Unmarshal([]byte(o.String()), &metrics); err != nil {
+		t.Fatalf("metrics %v: %v", o.String(), err)
+	}
+	if m := metrics["/s/unary"]; m.Calls != 3 || m.InFlight != 0 || m.Codes["OK"] != 2 || m.Codes["NotFound"] != 1 {
+		t.Errorf("unary metrics = %+v, want 3 calls, 2 OK and 1 NotFound", m)
+	}
+	if m := metrics["/s/stream"]; m.Calls != 1 || m.Codes["OK"] != 1 {
+		t.Errorf("stream metrics = %+v, want 1 OK call", m)
+	}
+}
+
+func TestMetricsServeOnlyGRPCVar(t *testing.T) {
+	o := NewObserver("[Test]")
+	o.UnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/s/unary"},
+		func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+	h := metricsHandler(o)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/vars", nil))
+	var vars map[string]map[string]methodStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("/debug/vars %q: %v", rec.Body, err)
+	}
+	if len(vars) != 1 || vars["grpc"]["/s/unary"].Calls != 1 {
+		t.Errorf("/debug/vars = %q, want only the grpc var with 1 call", rec.Body)
+	}
+
+	// Neither the command line nor the default handlers are exposed.
+	for _, path := range []string{"/", "/debug/pprof/", "/debug/vars/cmdline"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %v = %v, want 404", path, rec.Code)
+		}
+	}
+}
+
+func TestStreamCountsConcurrentMessages(t *testing.T) {
+	// Handlers may send from another goroutine than the one receiving.
+	cs := &countingStream{ServerStream: &fakeStream{n: 100}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			cs.SendMsg(nil)
+		}
+	}()
+	for cs.RecvMsg(nil) == nil {
+	}
+	<-done
+	if recv, sent := cs.recv.Load(), cs.sent.Load(); recv != 100 || sent != 100 {
+		t.Errorf("counted recv=%d sent=%d, want 100 each", recv, sent)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	_, err := UnaryServerRecovery("[Test]")(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/s/unary"},
+		func(context.Context, interface{}) (interface{}, error) { panic("secret") })
+	if status.Code(err) != codes.Internal || status.Convert(err).Message() == "secret" {
+		t.Errorf("unary panic = %v, want Internal without the panic value", err)
+	}
+	err = StreamServerRecovery("[Test]")(nil, &fakeStream{}, &grpc.StreamServerInfo{FullMethod: "/s/stream"},
+		func(interface{}, grpc.ServerStream) error { panic(errors.New("secret")) })
+	if status.Code(err) != codes.Internal {
+		t.Errorf("stream panic = %v, want Internal", err)
+	}
+	// Errors returned rather than panicked go through untouched.
+	_, err = UnaryServerRecovery("[Test]")(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/s/unary"},
+		func(context.Context, interface{}) (interface{}, error) {
+			return nil, status.Error(codes.NotFound, "no")
+		})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("error = %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,178 @@
+// Package middleware holds the server interceptors shared by the services:
+// an Observer logging every call and keeping per method metrics, and the
+// recovery interceptors turning a panicking handler into an Internal error.
+//
+// The observer goes first, so that it sees the outcome of every call,
+// including those rejected or recovered by the interceptors after it:
+//
+//	o := middleware.NewObserver("[Server]")
+//	s := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(o.UnaryServerInterceptor(), middleware.UnaryServerRecovery("[Server]"), ...),
+//		grpc.ChainStreamInterceptor(o.StreamServerInterceptor(), middleware.StreamServerRecovery("[Server]"), ...))
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Observer logs one line per call, with its method, peer, status code and
+// duration, and counts the calls of each method. It is an expvar.Var
+// rendering those counts as JSON, served by ServeMetrics.
+//
+// An Observer is safe for concurrent use.
+type Observer struct {
+	tag string
+
+	mu      sync.Mutex
+	methods map[string]*methodStats
+}
+
+var _ expvar.Var = (*Observer)(nil)
+
+// methodStats are the metrics of a single method.
+type methodStats struct {
+	Calls    int64            `json:"calls"`
+	InFlight int64            `json:"in_flight"`
+	Codes    map[string]int64 `json:"codes"`
+	TotalMs  float64          `json:"total_ms"`
+	MaxMs    float64          `json:"max_ms"`
+}
+
+// NewObserver returns an observer logging with tag, e.g. "[Server]".
+func NewObserver(tag string) *Observer {
+	return &Observer{tag: tag, methods: make(map[string]*methodStats)}
+}
+
+// UnaryServerInterceptor observes every unary call.
+func (o *Observer) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := o.begin(info.FullMethod)
+		resp, err := handler(ctx, req)
+		d := o.end(info.FullMethod, start, err)
+		o.log(ctx, "unary", info.FullMethod, d, err, "")
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor observes every streaming call, also logging the
+// number of messages received and sent.
+func (o *Observer) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := o.begin(info.FullMethod)
+		cs := &countingStream{ServerStream: ss}
+		err := handler(srv, cs)
+		d := o.end(info.FullMethod, start, err)
+		o.log(ss.Context(), "stream", info.FullMethod, d, err, fmt.Sprintf(" recv=%d sent=%d", cs.recv.Load(), cs.sent.Load()))
+		return err
+	}
+}
+
+func (o *Observer) begin(method string) time.Time {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.stats(method).InFlight++
+	return time.Now()
+}
+
+func (o *Observer) end(method string, start time.Time, err error) time.Duration {
+	d := time.Since(start)
+	ms := float64(d) / float64(time.Millisecond)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	m := o.stats(method)
+	m.InFlight--
+	m.Calls++
+	m.Codes[status.Code(err).String()]++
+	m.TotalMs += ms
+	if ms > m.MaxMs {
+		m.MaxMs = ms
+	}
+	return d
+}
+
+// stats returns the metrics of method, o.mu held.
+func (o *Observer) stats(method string) *methodStats {
+	m, ok := o.methods[method]
+	if !ok {
+		m = &methodStats{Codes: make(map[string]int64)}
+		o.methods[method] = m
+	}
+	return m
+}
+
+func (o *Observer) log(ctx context.Context, kind, method string, d time.Duration, err error, extra string) {
+	addr := "unknown"
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	st := status.Convert(err)
+	if err == nil {
+		log.Printf("%v call=%v method=%v peer=%v code=%v duration=%v%v\n", o.tag, kind, method, addr, st.Code(), d.Round(time.Microsecond), extra)
+		return
+	}
+	log.Printf("%v call=%v method=%v peer=%v code=%v duration=%v%v error=%q\n", o.tag, kind, method, addr, st.Code(), d.Round(time.Microsecond), extra, st.Message())
+}
+
+// String renders the metrics of every method called so far as JSON.
+func (o *Observer) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	b, err := json.Marshal(o.methods)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// ServeMetrics serves the metrics of o on addr at /debug/vars, in the format
+// of expvar, as the var "grpc". Nothing else is served: the default vars of
+// expvar include the command line, which may hold secrets. It only returns
+// when the HTTP server fails.
+func ServeMetrics(addr string, o *Observer) error {
+	return http.ListenAndServe(addr, metricsHandler(o))
+}
+
+// metricsHandler returns the handler of ServeMetrics.
+func metricsHandler(o *Observer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/vars", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprintf(w, "{\n%q: %v\n}\n", "grpc", o)
+	})
+	return mux
+}
+
+// countingStream counts the messages of a stream. Handlers may receive and
+// send from different goroutines, so the counts are atomic.
+type countingStream struct {
+	grpc.ServerStream
+	recv, sent atomic.Int64
+}
+
+func (s *countingStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.recv.Add(1)
+	}
+	return err
+}
+
+func (s *countingStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"ecommerce/rpcerr"
+	"log"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+)
+
+// UnaryServerRecovery turns a panic of a unary handler into an Internal
+// error, logging it with tag and the stack, instead of crashing the server.
+func UnaryServerRecovery(tag string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(tag, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerRecovery is UnaryServerRecovery for streaming handlers.
+func StreamServerRecovery(tag string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(tag, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recovered logs the panic r of method. The error returned to the caller
+// does not include r, which may reveal internals of the server.
+func recovered(tag, method string, r interface{}) error {
+	log.Printf("%v method=%v panic=%q\n%s", tag, method, r, debug.Stack())
+	return rpcerr.Internal("%v failed unexpectedly", method)
+}
@@ -0,0 +1,229 @@
+// Package money implements exact arithmetic on google.type.Money amounts.
+//
+// An amount is its whole units plus a number of nano (10^-9) units, both
+// carrying the same sign. Arithmetic is done on those integers, so sums of
+// prices such as 2299.00 and 0.10 never pick up floating point rounding.
+// Amounts of different currencies are never combined.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	moneypb "google.golang.org/genproto/googleapis/type/money"
+)
+
+const nanosPerUnit = 1_000_000_000
+
+var (
+	// ErrCurrencyMismatch is returned when combining amounts of different currencies.
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+	// ErrOverflow is returned when a result does not fit in 64 bit units.
+	ErrOverflow = errors.New("money: overflow")
+)
+
+// New returns the amount units + nanos/10^9 in currency, normalizing nanos
+// into [0, 10^9) with the sign of units.
+func New(currency string, units int64, nanos int32) *moneypb.Money {
+	m, _ := normalize(currency, units, int64(nanos))
+	return m
+}
+
+// FromFloat converts f, rounded to the cent, to an amount in currency.
+// It only exists to migrate the float prices of older records.
+func FromFloat(currency string, f float64) *moneypb.Money {
+	cents := int64(math.Round(f * 100))
+	return New(currency, cents/100, int32(cents%100)*10_000_000)
+}
+
+// Parse parses a decimal amount such as "2299", "-0.5" or "49.99" in currency.
+func Parse(currency, amount string) (*moneypb.Money, error) {
+	s := strings.TrimSpace(amount)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || len(frac) > 9 || !isDigits(whole) || !isDigits(frac) {
+		return nil, fmt.Errorf("money: invalid amount %q", amount)
+	}
+
+	var units, nanos int64
+	var err error
+	if whole != "" {
+		if units, err = strconv.ParseInt(whole, 10, 64); err != nil {
+			return nil, fmt.Errorf("money: invalid amount %q", amount)
+		}
+	}
+	if frac != "" {
+		if nanos, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64); err != nil {
+			return nil, fmt.Errorf("money: invalid amount %q", amount)
+		}
+	}
+	if neg {
+		units, nanos = -units, -nanos
+	}
+	return normalize(currency, units, nanos)
+}
+
+func isDigits(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }) < 0
+}
+
+// MustParse is like Parse but panics on an invalid amount.
+func MustParse(currency, amount string) *moneypb.Money {
+	m, err := Parse(currency, amount)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// normalize carries whole units out of nanos and gives both the same sign.
+func normalize(currency string, units, nanos int64) (*moneypb.Money, error) {
+	carry := nanos / nanosPerUnit
+	nanos %= nanosPerUnit
+	if (carry > 0 && units > math.MaxInt64-carry) || (carry < 0 && units < math.MinInt64-carry) {
+		return nil, ErrOverflow
+	}
+	units += carry
+	switch {
+	case units > 0 && nanos < 0:
+		units--
+		nanos += nanosPerUnit
+	case units < 0 && nanos > 0:
+		units++
+		nanos -= nanosPerUnit
+	}
+	return &moneypb.Money{CurrencyCode: currency, Units: units, Nanos: int32(nanos)}, nil
+}
+
+// Validate reports whether m is a well formed amount: a three letter upper
+// case ISO 4217 currency code and nanos in range with the sign of units.
+func Validate(m *moneypb.Money) error {
+	if m == nil {
+		return errors.New("money: amount is not set")
+	}
+	if len(m.CurrencyCode) != 3 || strings.ToUpper(m.CurrencyCode) != m.CurrencyCode ||
+		strings.IndexFunc(m.CurrencyCode, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0 {
+		return fmt.Errorf("money: invalid currency code %q", m.CurrencyCode)
+	}
+	if m.Nanos <= -nanosPerUnit || m.Nanos >= nanosPerUnit {
+		return fmt.Errorf("money: nanos %v out of range", m.Nanos)
+	}
+	if (m.Units > 0 && m.Nanos < 0) || (m.Units < 0 && m.Nanos > 0) {
+		return errors.New("money: units and nanos have different signs")
+	}
+	return nil
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of m. A nil amount is zero.
+func Sign(m *moneypb.Money) int {
+	switch {
+	case m.GetUnits() > 0 || m.GetNanos() > 0:
+		return 1
+	case m.GetUnits() < 0 || m.GetNanos() < 0:
+		return -1
+	}
+	return 0
+}
+
+// Add returns a + b, which must be of the same currency.
+func Add(a, b *moneypb.Money) (*moneypb.Money, error) {
+	if a.GetCurrencyCode() != b.GetCurrencyCode() {
+		return nil, fmt.Errorf("%w: %v and %v", ErrCurrencyMismatch, a.GetCurrencyCode(), b.GetCurrencyCode())
+	}
+	if (b.GetUnits() > 0 && a.GetUnits() > math.MaxInt64-b.GetUnits()) ||
+		(b.GetUnits() < 0 && a.GetUnits() < math.MinInt64-b.GetUnits()) {
+		return nil, ErrOverflow
+	}
+	return normalize(a.GetCurrencyCode(), a.GetUnits()+b.GetUnits(), int64(a.GetNanos())+int64(b.GetNanos()))
+}
+
+// Multiply returns m * n, e.g. the price of n items priced m each.
+func Multiply(m *moneypb.Money, n int64) (*moneypb.Money, error) {
+	units, nanos := m.GetUnits(), int64(m.GetNanos())
+	if n != 0 && ((units*n)/n != units || (nanos*n)/n != nanos) {
+		return nil, ErrOverflow
+	}
+	return normalize(m.GetCurrencyCode(), units*n, nanos*n)
+}
+
+// Sum returns the total of amounts in currency, zero if there are none.
+func Sum(currency string, amounts ...*moneypb.Money) (*moneypb.Money, error) {
+	total := New(currency, 0, 0)
+	for _, m := range amounts {
+		var err error
+		if total, err = Add(total, m); err != nil {
+			return nil, err
+		}
+	}
+	return total, nil
+}
+
+// Compare orders amounts by currency code, then by value, returning -1, 0 or
+// +1. A nil amount sorts before any other.
+func Compare(a, b *moneypb.Money) int {
+	switch {
+	case a == nil || b == nil:
+		return compareInts(boolInt(a != nil), boolInt(b != nil))
+	case a.CurrencyCode != b.CurrencyCode:
+		return strings.Compare(a.CurrencyCode, b.CurrencyCode)
+	case a.Units != b.Units:
+		return compareInts(a.Units, b.Units)
+	}
+	return compareInts(int64(a.Nanos), int64(b.Nanos))
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Float64 approximates m as a float, for display and coarse thresholds only.
+func Float64(m *moneypb.Money) float64 {
+	return float64(m.GetUnits()) + float64(m.GetNanos())/nanosPerUnit
+}
+
+// Format renders m as a decimal amount with at least two fraction digits
+// followed by its currency code, e.g. "2299.00 USD".
+func Format(m *moneypb.Money) string {
+	if m == nil {
+		return "<nil>"
+	}
+	units, nanos := m.Units, int64(m.Nanos)
+	sign := ""
+	if units < 0 || nanos < 0 {
+		sign = "-"
+	}
+	frac := strings.TrimRight(fmt.Sprintf("%09d", abs(nanos)), "0")
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	return fmt.Sprintf("%v%v.%v %v", sign, absUnits(units), frac, m.CurrencyCode)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// absUnits formats the magnitude of units, which may be math.MinInt64.
+func absUnits(units int64) string {
+	return strings.TrimPrefix(strconv.FormatInt(units, 10), "-")
+}
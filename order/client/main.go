@@ -2,23 +2,42 @@ package main
 
 import (
 	"context"
+	"ecommerce/auth"
+	inventorypb "ecommerce/inventory/proto"
+	"ecommerce/money"
 	pb "ecommerce/order/proto"
+	productpb "ecommerce/product/proto"
+	"ecommerce/rpcerr"
+	"ecommerce/tlsconfig"
+	"flag"
 	"fmt"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"io"
 	"log"
 	"time"
 )
 
 const (
-	addr = "localhost:50082" // 71: java, 81: go
-	tag  = "[Client]"
+	addr          = "localhost:50082" // 71: java, 81: go
+	productAddr   = "localhost:50081"
+	inventoryAddr = "localhost:50083"
+	tag           = "[Client]"
+)
+
+var (
+	clientTLS  = tlsconfig.ClientFlags("")
+	clientAuth = auth.ClientFlags("")
 )
 
 func main() {
+	flag.Parse()
+
 	// Setting up a connection to the server.
-	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()),
+	conn, err := dial(addr,
 		grpc.WithUnaryInterceptor(orderUnaryClientInterceptor),
 		grpc.WithStreamInterceptor(clientStreamInterceptor))
 
@@ -37,11 +56,40 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
 	defer cancel()
 
+	stopWatching := watchOrders(ctx, c)
 	id := addOrder(ctx, c)
+	addOrderWithGeneratedId(ctx, c)
+	addInvalidOrder(ctx, c)
+	addOrderWithLineItems(ctx, c)
 	getOrder(ctx, c, id)
-	searchOrders(ctx, c, "Google")
-	updateOrders(ctx, c)
+	searchOrders(ctx, c, &pb.SearchRequest{S: "Google"})
+	// Orders to Mountain View, or any order over 1000, most expensive first.
+	searchOrders(ctx, c, &pb.SearchRequest{
+		Filter: &pb.Filter{Kind: &pb.Filter_AnyOf{AnyOf: &pb.FilterList{Filters: []*pb.Filter{
+			{Kind: &pb.Filter_Destination{Destination: &pb.StringMatch{Value: "mountain view", Mode: pb.MatchMode_MATCH_MODE_PREFIX, IgnoreCase: true}}},
+			{Kind: &pb.Filter_Price{Price: &pb.PriceRange{Min: money.New("USD", 1000, 0)}}},
+		}}}},
+		SortBy:     pb.SortField_SORT_FIELD_PRICE,
+		Descending: true,
+		Limit:      3,
+	})
+	updateOrders(ctx, c, []*pb.Order{
+		{Id: "102", Items: []string{"Google Pixel 3A", "Google Pixel Book"}, Destination: "Mountain View, CA", Price: money.MustParse("USD", "1100.00")},
+		// Legacy destinations and structured addresses are combined into the
+		// same shipments once normalized.
+		{Id: "103", Items: []string{"Apple Watch S4", "Mac Book Pro", "iPad Pro"}, Destination: "San Jose,CA", Price: money.MustParse("USD", "2800.00")},
+		{Id: "104", Items: []string{"Google Home Mini", "Google Nest Hub", "iPad Mini"}, Address: &pb.Address{City: "Mountain  View", Region: "ca"}, Price: money.MustParse("USD", "2200.00")},
+	}, false)
+	updateOrderWithVersion(ctx, c, "105")
 	processOrders(ctx, c)
+	if shipments := listShipments(ctx, c, pb.ShipmentStatus_SHIPMENT_STATUS_PROCESSING); len(shipments) > 0 {
+		getShipment(ctx, c, shipments[0].Id)
+		trackShipment(ctx, c, shipments[0])
+	}
+	transitionOrder(ctx, c, "105", pb.OrderStatus_ORDER_STATUS_CONFIRMED)
+	cancelOrder(ctx, c, "106")
+	cancelOrder(ctx, c, "106") // already cancelled, rejected by the server
+	stopWatching()
 }
 
 // Add Order
@@ -50,18 +98,112 @@ func addOrder(ctx context.Context, c pb.OrderManagementClient) string {
 	log.Printf("%v [Invoked]\n", tag0)
 	defer log.Printf("%v [End]\n\n", tag0)
 
-	ord := pb.Order{Id: "101", Items: []string{"iPhone XS", "Mac Book Pro"}, Destination: "San Jose, CA", Price: 2299.00}
+	ord := pb.Order{Id: "101", Items: []string{"iPhone XS", "Mac Book Pro"}, Destination: "San Jose, CA", Price: money.MustParse("USD", "2299.00")}
 	log.Printf("%v [Creating] %v\n", tag0, &ord)
 
-	_, err := c.AddOrder(ctx, &ord)
-	if err != nil {
-		log.Printf("%v[Error] %v\n\n", tag0, err)
-		return ""
+	// The idempotency key makes it safe to retry, or to rerun this client,
+	// without getting AlreadyExists for order 101.
+	ctx = metadata.AppendToOutgoingContext(ctx, "idempotency-key", "client-add-101")
+	for attempt := 1; attempt <= 2; attempt++ {
+		orderId, err := c.AddOrder(ctx, &ord)
+		if err != nil {
+			log.Printf("%v[Error] %v\n\n", tag0, rpcerr.Describe(err))
+			return ""
+		}
+		log.Printf("%v [Success] attempt %v: %v\n", tag0, attempt, orderId.Id)
 	}
-	log.Printf("%v [Success]\n", tag0)
 	return ord.Id
 }
 
+// Add Order, letting the server pick the id
+func addOrderWithGeneratedId(ctx context.Context, c pb.OrderManagementClient) {
+	tag0 := tag + " [C]"
+	log.Printf("%v [Invoked]\n", tag0)
+	defer log.Printf("%v [End]\n\n", tag0)
+
+	ord := pb.Order{Items: []string{"Google Pixel Buds"}, Destination: "Palo Alto, CA", Price: money.MustParse("USD", "179.00")}
+	orderId, err := c.AddOrder(ctx, &ord)
+	if err != nil {
+		log.Printf("%v[Error] %v\n\n", tag0, rpcerr.Describe(err))
+		return
+	}
+	log.Printf("%v [Success] %v\n", tag0, orderId.Id)
+}
+
+// Add Order that fails validation
+func addInvalidOrder(ctx context.Context, c pb.OrderManagementClient) {
+	tag0 := tag + " [C]"
+	log.Printf("%v [Invoked]\n", tag0)
+	defer log.Printf("%v [End]\n\n", tag0)
+
+	_, err := c.AddOrder(ctx, &pb.Order{Destination: "San Jose, CA", Price: money.New("USD", -10, 0)})
+	log.Printf("%v [Invalid] %v\n", tag0, rpcerr.Describe(err))
+}
+
+// Add Order of catalog products, priced by the server
+func addOrderWithLineItems(ctx context.Context, c pb.OrderManagementClient) {
+	tag0 := tag + " [C]"
+	log.Printf("%v [Invoked]\n", tag0)
+	defer log.Printf("%v [End]\n\n", tag0)
+
+	conn, err := dial(productAddr)
+	if err != nil {
+		log.Printf("%v [Error] %v\n\n", tag0, err)
+		return
+	}
+	defer conn.Close()
+	product, err := productpb.NewProductInfoClient(conn).AddProduct(ctx, &productpb.Product{
+		Name: "Google Pixel 7", Description: "Made by Google.", Price: money.MustParse("USD", "599.00"),
+	})
+	if err != nil {
+		log.Printf("%v [Error] product service: %v\n\n", tag0, rpcerr.Describe(err))
+		return
+	}
+
+	// Only 3 in stock: the first order reserves 2, leaving too few for the second.
+	inventoryConn, err := dial(inventoryAddr)
+	if err != nil {
+		log.Printf("%v [Error] %v\n\n", tag0, err)
+		return
+	}
+	defer inventoryConn.Close()
+	_, err = inventorypb.NewInventoryClient(inventoryConn).SetStock(ctx, &inventorypb.SetStockRequest{ProductId: product.Value, OnHand: 3})
+	if err != nil {
+		log.Printf("%v [Error] inventory service: %v\n\n", tag0, rpcerr.Describe(err))
+		return
+	}
+
+	orderId, err := c.AddOrder(ctx, &pb.Order{
+		Destination: "Mountain View, CA",
+		LineItems:   []*pb.LineItem{{ProductId: product.Value, Quantity: 2}},
+	})
+	if err != nil {
+		log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
+		return
+	}
+	ord, err := c.GetOrder(ctx, orderId)
+	if err != nil {
+		log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
+		return
+	}
+	for _, item := range ord.LineItems {
+		log.Printf("%v [Line Item] %v x %v @ %v\n", tag0, item.Quantity, item.Name, money.Format(item.UnitPrice))
+	}
+	log.Printf("%v [Success] %v total %v, reservation %v\n", tag0, ord.Id, money.Format(ord.Price), ord.ReservationId)
+
+	_, err = c.AddOrder(ctx, &pb.Order{
+		Destination: "Mountain View, CA",
+		LineItems:   []*pb.LineItem{{ProductId: product.Value, Quantity: 2}},
+	})
+	log.Printf("%v [Out Of Stock] %v\n", tag0, rpcerr.Describe(err))
+
+	_, err = c.AddOrder(ctx, &pb.Order{
+		Destination: "Mountain View, CA",
+		LineItems:   []*pb.LineItem{{ProductId: "no-such-product", Quantity: 1}},
+	})
+	log.Printf("%v [Unknown Product] %v\n", tag0, rpcerr.Describe(err))
+}
+
 // Get Order
 func getOrder(ctx context.Context, c pb.OrderManagementClient, id string) {
 	tag0 := tag + " [R]"
@@ -71,19 +213,51 @@ func getOrder(ctx context.Context, c pb.OrderManagementClient, id string) {
 	ord, err := c.GetOrder(ctx, &pb.OrderId{Id: id})
 
 	if err != nil {
-		log.Printf("%v [Error] %v\n\n", tag0, err)
+		log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
 	}
 
 	log.Printf("%v [Success] %v\n", tag0, ord)
 }
 
+// Transition Order
+func transitionOrder(ctx context.Context, c pb.OrderManagementClient, id string, st pb.OrderStatus) {
+	tag0 := tag + " [T]"
+	log.Printf("%v [Invoked]\n", tag0)
+	defer log.Printf("%v [End]\n\n", tag0)
+
+	ord, err := c.TransitionOrder(ctx, &pb.TransitionRequest{Id: id, Status: st})
+	if err != nil {
+		log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
+		return
+	}
+	log.Printf("%v [Success] %v\n", tag0, ord)
+}
+
+// Cancel Order
+func cancelOrder(ctx context.Context, c pb.OrderManagementClient, id string) {
+	tag0 := tag + " [X]"
+	log.Printf("%v [Invoked]\n", tag0)
+	defer log.Printf("%v [End]\n\n", tag0)
+
+	ord, err := c.CancelOrder(ctx, &pb.OrderId{Id: id})
+	if err != nil {
+		log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
+		return
+	}
+	log.Printf("%v [Success] %v\n", tag0, ord)
+}
+
 // Search Order : Server streaming scenario
-func searchOrders(ctx context.Context, c pb.OrderManagementClient, s string) {
+func searchOrders(ctx context.Context, c pb.OrderManagementClient, req *pb.SearchRequest) {
 	tag0 := tag + " [SS]"
 	log.Printf("%v [Invoked]\n", tag0)
 	defer log.Printf("%v [End]\n\n", tag0)
 
-	stream, _ := c.SearchOrders(ctx, &pb.SearchRequest{S: s})
+	stream, err := c.SearchOrders(ctx, req)
+	if err != nil {
+		log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
+		return
+	}
 	for {
 		order, err := stream.Recv()
 
@@ -93,8 +267,9 @@ func searchOrders(ctx context.Context, c pb.OrderManagementClient, s string) {
 		}
 
 		if err != nil {
-			log.Printf("%v [Error] %v\n\n", tag0, err)
-			continue
+			// The stream ends with the first error, e.g. an invalid filter.
+			log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
+			break
 		}
 
 		log.Printf("%v [Recv] %v\n", tag0, order)
@@ -103,17 +278,15 @@ func searchOrders(ctx context.Context, c pb.OrderManagementClient, s string) {
 }
 
 // Update Orders : Client streaming scenario
-func updateOrders(ctx context.Context, c pb.OrderManagementClient) {
+func updateOrders(ctx context.Context, c pb.OrderManagementClient, orders []*pb.Order, atomic bool) {
 	tag0 := tag + " [CS]"
 	log.Printf("%v [Invoked]\n", tag0)
 	defer log.Printf("%v [End]\n\n", tag0)
 
-	orders := []*pb.Order{
-		{Id: "102", Items: []string{"Google Pixel 3A", "Google Pixel Book"}, Destination: "Mountain View, CA", Price: 1100.00},
-		{Id: "103", Items: []string{"Apple Watch S4", "Mac Book Pro", "iPad Pro"}, Destination: "San Jose, CA", Price: 2800.00},
-		{Id: "104", Items: []string{"Google Home Mini", "Google Nest Hub", "iPad Mini"}, Destination: "Mountain View, CA", Price: 2200.00},
+	if atomic {
+		// All orders of the stream are applied, or none.
+		ctx = metadata.AppendToOutgoingContext(ctx, "update-mode", "atomic")
 	}
-
 	stream, err := c.UpdateOrders(ctx)
 
 	if err != nil {
@@ -122,15 +295,45 @@ func updateOrders(ctx context.Context, c pb.OrderManagementClient) {
 
 	for _, ord := range orders {
 		if err := stream.Send(ord); err != nil {
-			log.Fatalf("%v %v.Send(%v) = %v", tag0, stream, &ord, err)
+			log.Fatalf("%v %v.Send(%v) = %v", tag0, stream, ord, err)
 		}
 	}
 
-	ids, err := stream.CloseAndRecv()
+	resp, err := stream.CloseAndRecv()
 	if err != nil {
 		log.Fatalf("%v %v.CloseAndRecv() got error %v, want %v\n\n", tag0, stream, err, nil)
 	}
-	log.Printf("%v [Success] %s\n", tag0, ids)
+	log.Printf("%v [Success] %s committed: %v\n", tag0, resp.Id, resp.Committed)
+	for _, result := range resp.Results {
+		if result.Status != nil {
+			log.Printf("%v [Result] %v %v: %v\n", tag0, result.Id, result.Outcome, rpcerr.Describe(status.ErrorProto(result.Status)))
+			continue
+		}
+		log.Printf("%v [Result] %v %v version %v\n", tag0, result.Id, result.Outcome, result.Version)
+	}
+}
+
+// Update an order only if nobody changed it since it was read
+func updateOrderWithVersion(ctx context.Context, c pb.OrderManagementClient, id string) {
+	ord, err := c.GetOrder(ctx, &pb.OrderId{Id: id})
+	if err != nil {
+		log.Printf("%v [CS] [Error] %v\n\n", tag, rpcerr.Describe(err))
+		return
+	}
+
+	// The second write still carries the version read above and is rejected.
+	for _, desc := range []string{"Gift wrapped", "Leave at the door"} {
+		update := proto.Clone(ord).(*pb.Order)
+		update.Description = desc
+		updateOrders(ctx, c, []*pb.Order{update}, false)
+	}
+
+	// Rolled back as a whole because of the same stale version.
+	fresh := proto.Clone(ord).(*pb.Order)
+	fresh.Version = 0
+	fresh.Description = "Fragile"
+	stale := proto.Clone(ord).(*pb.Order)
+	updateOrders(ctx, c, []*pb.Order{fresh, stale}, true)
 }
 
 // =========================================
@@ -145,8 +348,11 @@ func processOrders(ctx context.Context, client pb.OrderManagementClient) {
 		{Id: "103"},
 		{Id: "104"},
 		{Id: "101"},
+		{Id: "999"}, // unknown, rejected by the server
 	}
 
+	// Ship each destination as soon as it has two orders.
+	ctx = metadata.AppendToOutgoingContext(ctx, "batch-policy", "destination", "batch-size", "2")
 	stream, err := client.ProcessOrders(ctx)
 
 	if err != nil {
@@ -172,16 +378,21 @@ func processOrders(ctx context.Context, client pb.OrderManagementClient) {
 func asyncClientBidirectionalRPC(streamProcOrder pb.OrderManagement_ProcessOrdersClient, c chan<- struct{}) {
 	tag0 := tag + " [BI]"
 	for {
-		combinedShipment, err := streamProcOrder.Recv()
+		result, err := streamProcOrder.Recv()
 		if err == io.EOF {
 			log.Printf("%v [%v]\n", tag0, err)
 			break
 		}
 		if err != nil {
-			log.Printf("%v [Error] %v\n", tag0, err)
+			log.Printf("%v [Error] %v\n", tag0, rpcerr.Describe(err))
 			break
 		}
-		msg := fmt.Sprintf("%v\n\tCombined shipment :\n", tag0)
+		if rejection := result.GetRejection(); rejection != nil {
+			log.Printf("%v [Rejected] %v: %v\n", tag0, rejection.OrderId, rpcerr.Describe(status.ErrorProto(rejection.Status)))
+			continue
+		}
+		combinedShipment := result.GetShipment()
+		msg := fmt.Sprintf("%v\n\tCombined shipment %v to %v: %v\n", tag0, combinedShipment.Id, combinedShipment.Destination, money.Format(combinedShipment.Total))
 		for _, ord := range combinedShipment.OrdersList {
 			msg += fmt.Sprintf("\t\t%v\n", ord)
 		}
@@ -190,6 +401,168 @@ func asyncClientBidirectionalRPC(streamProcOrder pb.OrderManagement_ProcessOrder
 	c <- struct{}{}
 }
 
+// Watch Orders in the background until the returned function is called,
+// which then resumes the feed from the first change seen to replay the rest.
+func watchOrders(ctx context.Context, c pb.OrderManagementClient) func() {
+	tag0 := tag + " [W]"
+	log.Printf("%v [Invoked]\n", tag0)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	stream, err := c.WatchOrders(watchCtx, &pb.WatchOrdersRequest{})
+	if err != nil {
+		log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
+		cancel()
+		return func() {}
+	}
+
+	var first int64
+	received := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			event, err := stream.Recv()
+			if status.Code(err) == codes.Canceled {
+				return
+			}
+			if err != nil {
+				log.Printf("%v [Error] %v\n", tag0, rpcerr.Describe(err))
+				return
+			}
+			if first == 0 {
+				first = event.Revision
+			}
+			received++
+			id := event.After.GetId()
+			if event.After == nil {
+				id = event.Before.GetId()
+			}
+			log.Printf("%v [Event] %v %v %v\n", tag0, event.Revision, event.Type, id)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		log.Printf("%v [Received] %v events\n", tag0, received)
+		defer log.Printf("%v [End]\n\n", tag0)
+		if first == 0 {
+			return
+		}
+
+		// Resume as if reconnecting after the first event.
+		resumeCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		stream, err := c.WatchOrders(resumeCtx, &pb.WatchOrdersRequest{AfterRevision: first})
+		if err != nil {
+			log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
+			return
+		}
+		replayed := 0
+		for {
+			if _, err := stream.Recv(); err != nil {
+				if status.Code(err) != codes.DeadlineExceeded {
+					log.Printf("%v [Error] %v\n", tag0, rpcerr.Describe(err))
+				}
+				break
+			}
+			replayed++
+		}
+		log.Printf("%v [Resumed] after revision %v: %v events replayed\n", tag0, first, replayed)
+	}
+}
+
+// List Shipments, one per page to walk the page tokens
+func listShipments(ctx context.Context, c pb.OrderManagementClient, st pb.ShipmentStatus) []*pb.CombinedShipment {
+	tag0 := tag + " [LS]"
+	log.Printf("%v [Invoked]\n", tag0)
+	defer log.Printf("%v [End]\n\n", tag0)
+
+	var shipments []*pb.CombinedShipment
+	req := &pb.ListShipmentsRequest{PageSize: 1, Status: st}
+	for {
+		resp, err := c.ListShipments(ctx, req)
+		if err != nil {
+			log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
+			return shipments
+		}
+		for _, shipment := range resp.Shipments {
+			log.Printf("%v [Shipment] %v to %v: %v orders, %v\n", tag0, shipment.Id, shipment.Destination, len(shipment.OrdersList), shipment.Status)
+		}
+		shipments = append(shipments, resp.Shipments...)
+		if resp.NextPageToken == "" {
+			return shipments
+		}
+		req.PageToken = resp.NextPageToken
+	}
+}
+
+// Get Shipment
+func getShipment(ctx context.Context, c pb.OrderManagementClient, id string) {
+	tag0 := tag + " [GS]"
+	log.Printf("%v [Invoked]\n", tag0)
+	defer log.Printf("%v [End]\n\n", tag0)
+
+	shipment, err := c.GetShipment(ctx, &pb.ShipmentId{Id: id})
+	if err != nil {
+		log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
+		return
+	}
+	log.Printf("%v [Success] %v\n", tag0, shipment)
+}
+
+// Track Shipment while its orders are shipped, then delivered
+func trackShipment(ctx context.Context, c pb.OrderManagementClient, shipment *pb.CombinedShipment) {
+	tag0 := tag + " [TS]"
+	log.Printf("%v [Invoked]\n", tag0)
+	defer log.Printf("%v [End]\n\n", tag0)
+
+	stream, err := c.TrackShipment(ctx, &pb.ShipmentId{Id: shipment.Id})
+	if err != nil {
+		log.Printf("%v [Error] %v\n\n", tag0, rpcerr.Describe(err))
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			update, err := stream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				log.Printf("%v [Error] %v\n", tag0, rpcerr.Describe(err))
+				return
+			}
+			event := update.Events[len(update.Events)-1]
+			log.Printf("%v [Status] %v %v at %v\n", tag0, update.Id, update.Status, event.Time.AsTime().Format(time.RFC3339Nano))
+		}
+	}()
+
+	for _, st := range []pb.OrderStatus{pb.OrderStatus_ORDER_STATUS_SHIPPED, pb.OrderStatus_ORDER_STATUS_DELIVERED} {
+		for _, ord := range shipment.OrdersList {
+			transitionOrder(ctx, c, ord.Id, st)
+		}
+	}
+	<-done
+}
+
+// dial connects to target with the credentials set up by the tls-* and
+// token flags.
+func dial(target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	creds, err := clientTLS.Credentials()
+	if err != nil {
+		return nil, err
+	}
+	authOpts, err := clientAuth.DialOptions(clientTLS.Enabled())
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, authOpts...)
+	return grpc.Dial(target, append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, opts...)...)
+}
+
 func orderUnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 	// Pre-processor phase
 	log.Printf("%v [Unary] %v\n", tag, method)
@@ -27,6 +27,17 @@ type OrderManagementClient interface {
 	SearchOrders(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (OrderManagement_SearchOrdersClient, error)
 	UpdateOrders(ctx context.Context, opts ...grpc.CallOption) (OrderManagement_UpdateOrdersClient, error)
 	ProcessOrders(ctx context.Context, opts ...grpc.CallOption) (OrderManagement_ProcessOrdersClient, error)
+	CancelOrder(ctx context.Context, in *OrderId, opts ...grpc.CallOption) (*Order, error)
+	TransitionOrder(ctx context.Context, in *TransitionRequest, opts ...grpc.CallOption) (*Order, error)
+	GetShipment(ctx context.Context, in *ShipmentId, opts ...grpc.CallOption) (*CombinedShipment, error)
+	ListShipments(ctx context.Context, in *ListShipmentsRequest, opts ...grpc.CallOption) (*ListShipmentsResponse, error)
+	// Streams the shipment, then the shipment again on every status change,
+	// until it is DELIVERED or CANCELLED.
+	TrackShipment(ctx context.Context, in *ShipmentId, opts ...grpc.CallOption) (OrderManagement_TrackShipmentClient, error)
+	// Streams order changes until the client goes away. Fails with
+	// OutOfRange when after_revision is no longer, or not yet, in the
+	// history kept by the server.
+	WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (OrderManagement_WatchOrdersClient, error)
 }
 
 type orderManagementClient struct {
@@ -132,7 +143,7 @@ func (c *orderManagementClient) ProcessOrders(ctx context.Context, opts ...grpc.
 
 type OrderManagement_ProcessOrdersClient interface {
 	Send(*OrderId) error
-	Recv() (*CombinedShipment, error)
+	Recv() (*ProcessResult, error)
 	grpc.ClientStream
 }
 
@@ -144,7 +155,75 @@ func (x *orderManagementProcessOrdersClient) Send(m *OrderId) error {
 	return x.ClientStream.SendMsg(m)
 }
 
-func (x *orderManagementProcessOrdersClient) Recv() (*CombinedShipment, error) {
+func (x *orderManagementProcessOrdersClient) Recv() (*ProcessResult, error) {
+	m := new(ProcessResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *orderManagementClient) CancelOrder(ctx context.Context, in *OrderId, opts ...grpc.CallOption) (*Order, error) {
+	out := new(Order)
+	err := c.cc.Invoke(ctx, "/ecommerce.OrderManagement/cancelOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderManagementClient) TransitionOrder(ctx context.Context, in *TransitionRequest, opts ...grpc.CallOption) (*Order, error) {
+	out := new(Order)
+	err := c.cc.Invoke(ctx, "/ecommerce.OrderManagement/transitionOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderManagementClient) GetShipment(ctx context.Context, in *ShipmentId, opts ...grpc.CallOption) (*CombinedShipment, error) {
+	out := new(CombinedShipment)
+	err := c.cc.Invoke(ctx, "/ecommerce.OrderManagement/getShipment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderManagementClient) ListShipments(ctx context.Context, in *ListShipmentsRequest, opts ...grpc.CallOption) (*ListShipmentsResponse, error) {
+	out := new(ListShipmentsResponse)
+	err := c.cc.Invoke(ctx, "/ecommerce.OrderManagement/listShipments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderManagementClient) TrackShipment(ctx context.Context, in *ShipmentId, opts ...grpc.CallOption) (OrderManagement_TrackShipmentClient, error) {
+	stream, err := c.cc.NewStream(ctx, &OrderManagement_ServiceDesc.Streams[3], "/ecommerce.OrderManagement/trackShipment", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &orderManagementTrackShipmentClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type OrderManagement_TrackShipmentClient interface {
+	Recv() (*CombinedShipment, error)
+	grpc.ClientStream
+}
+
+type orderManagementTrackShipmentClient struct {
+	grpc.ClientStream
+}
+
+func (x *orderManagementTrackShipmentClient) Recv() (*CombinedShipment, error) {
 	m := new(CombinedShipment)
 	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
@@ -152,6 +231,38 @@ func (x *orderManagementProcessOrdersClient) Recv() (*CombinedShipment, error) {
 	return m, nil
 }
 
+func (c *orderManagementClient) WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (OrderManagement_WatchOrdersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &OrderManagement_ServiceDesc.Streams[4], "/ecommerce.OrderManagement/watchOrders", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &orderManagementWatchOrdersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type OrderManagement_WatchOrdersClient interface {
+	Recv() (*OrderEvent, error)
+	grpc.ClientStream
+}
+
+type orderManagementWatchOrdersClient struct {
+	grpc.ClientStream
+}
+
+func (x *orderManagementWatchOrdersClient) Recv() (*OrderEvent, error) {
+	m := new(OrderEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // OrderManagementServer is the server API for OrderManagement service.
 // All implementations must embed UnimplementedOrderManagementServer
 // for forward compatibility
@@ -161,6 +272,17 @@ type OrderManagementServer interface {
 	SearchOrders(*SearchRequest, OrderManagement_SearchOrdersServer) error
 	UpdateOrders(OrderManagement_UpdateOrdersServer) error
 	ProcessOrders(OrderManagement_ProcessOrdersServer) error
+	CancelOrder(context.Context, *OrderId) (*Order, error)
+	TransitionOrder(context.Context, *TransitionRequest) (*Order, error)
+	GetShipment(context.Context, *ShipmentId) (*CombinedShipment, error)
+	ListShipments(context.Context, *ListShipmentsRequest) (*ListShipmentsResponse, error)
+	// Streams the shipment, then the shipment again on every status change,
+	// until it is DELIVERED or CANCELLED.
+	TrackShipment(*ShipmentId, OrderManagement_TrackShipmentServer) error
+	// Streams order changes until the client goes away. Fails with
+	// OutOfRange when after_revision is no longer, or not yet, in the
+	// history kept by the server.
+	WatchOrders(*WatchOrdersRequest, OrderManagement_WatchOrdersServer) error
 	mustEmbedUnimplementedOrderManagementServer()
 }
 
@@ -183,6 +305,24 @@ func (UnimplementedOrderManagementServer) UpdateOrders(OrderManagement_UpdateOrd
 func (UnimplementedOrderManagementServer) ProcessOrders(OrderManagement_ProcessOrdersServer) error {
 	return status.Errorf(codes.Unimplemented, "method ProcessOrders not implemented")
 }
+func (UnimplementedOrderManagementServer) CancelOrder(context.Context, *OrderId) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelOrder not implemented")
+}
+func (UnimplementedOrderManagementServer) TransitionOrder(context.Context, *TransitionRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransitionOrder not implemented")
+}
+func (UnimplementedOrderManagementServer) GetShipment(context.Context, *ShipmentId) (*CombinedShipment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetShipment not implemented")
+}
+func (UnimplementedOrderManagementServer) ListShipments(context.Context, *ListShipmentsRequest) (*ListShipmentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListShipments not implemented")
+}
+func (UnimplementedOrderManagementServer) TrackShipment(*ShipmentId, OrderManagement_TrackShipmentServer) error {
+	return status.Errorf(codes.Unimplemented, "method TrackShipment not implemented")
+}
+func (UnimplementedOrderManagementServer) WatchOrders(*WatchOrdersRequest, OrderManagement_WatchOrdersServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrders not implemented")
+}
 func (UnimplementedOrderManagementServer) mustEmbedUnimplementedOrderManagementServer() {}
 
 // UnsafeOrderManagementServer may be embedded to opt out of forward compatibility for this service.
@@ -284,7 +424,7 @@ func _OrderManagement_ProcessOrders_Handler(srv interface{}, stream grpc.ServerS
 }
 
 type OrderManagement_ProcessOrdersServer interface {
-	Send(*CombinedShipment) error
+	Send(*ProcessResult) error
 	Recv() (*OrderId, error)
 	grpc.ServerStream
 }
@@ -293,7 +433,7 @@ type orderManagementProcessOrdersServer struct {
 	grpc.ServerStream
 }
 
-func (x *orderManagementProcessOrdersServer) Send(m *CombinedShipment) error {
+func (x *orderManagementProcessOrdersServer) Send(m *ProcessResult) error {
 	return x.ServerStream.SendMsg(m)
 }
 
@@ -305,6 +445,120 @@ func (x *orderManagementProcessOrdersServer) Recv() (*OrderId, error) {
 	return m, nil
 }
 
+func _OrderManagement_CancelOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrderId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderManagementServer).CancelOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.OrderManagement/cancelOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderManagementServer).CancelOrder(ctx, req.(*OrderId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderManagement_TransitionOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransitionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderManagementServer).TransitionOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.OrderManagement/transitionOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderManagementServer).TransitionOrder(ctx, req.(*TransitionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderManagement_GetShipment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShipmentId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderManagementServer).GetShipment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.OrderManagement/getShipment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderManagementServer).GetShipment(ctx, req.(*ShipmentId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderManagement_ListShipments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListShipmentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderManagementServer).ListShipments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ecommerce.OrderManagement/listShipments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderManagementServer).ListShipments(ctx, req.(*ListShipmentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderManagement_TrackShipment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ShipmentId)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderManagementServer).TrackShipment(m, &orderManagementTrackShipmentServer{stream})
+}
+
+type OrderManagement_TrackShipmentServer interface {
+	Send(*CombinedShipment) error
+	grpc.ServerStream
+}
+
+type orderManagementTrackShipmentServer struct {
+	grpc.ServerStream
+}
+
+func (x *orderManagementTrackShipmentServer) Send(m *CombinedShipment) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _OrderManagement_WatchOrders_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrdersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderManagementServer).WatchOrders(m, &orderManagementWatchOrdersServer{stream})
+}
+
+type OrderManagement_WatchOrdersServer interface {
+	Send(*OrderEvent) error
+	grpc.ServerStream
+}
+
+type orderManagementWatchOrdersServer struct {
+	grpc.ServerStream
+}
+
+func (x *orderManagementWatchOrdersServer) Send(m *OrderEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // OrderManagement_ServiceDesc is the grpc.ServiceDesc for OrderManagement service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -320,6 +574,22 @@ var OrderManagement_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "getOrder",
 			Handler:    _OrderManagement_GetOrder_Handler,
 		},
+		{
+			MethodName: "cancelOrder",
+			Handler:    _OrderManagement_CancelOrder_Handler,
+		},
+		{
+			MethodName: "transitionOrder",
+			Handler:    _OrderManagement_TransitionOrder_Handler,
+		},
+		{
+			MethodName: "getShipment",
+			Handler:    _OrderManagement_GetShipment_Handler,
+		},
+		{
+			MethodName: "listShipments",
+			Handler:    _OrderManagement_ListShipments_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -338,6 +608,16 @@ var OrderManagement_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "trackShipment",
+			Handler:       _OrderManagement_TrackShipment_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "watchOrders",
+			Handler:       _OrderManagement_WatchOrders_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "order/proto/order_management.proto",
 }
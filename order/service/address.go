@@ -0,0 +1,102 @@
+package main
+
+import (
+	pb "ecommerce/order/proto"
+	"strings"
+	"unicode"
+)
+
+// normalizeAddress returns a copy of a with the whitespace of every field
+// trimmed and collapsed, and region, postal code and country upper cased.
+func normalizeAddress(a *pb.Address) *pb.Address {
+	return &pb.Address{
+		Street:     collapseSpaces(a.GetStreet()),
+		City:       collapseSpaces(a.GetCity()),
+		Region:     strings.ToUpper(collapseSpaces(a.GetRegion())),
+		PostalCode: strings.ToUpper(collapseSpaces(a.GetPostalCode())),
+		Country:    strings.ToUpper(collapseSpaces(a.GetCountry())),
+	}
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// parseDestination reads a legacy free-text destination as comma separated
+// "street, city, region postal code, country" parts, anchored at the city:
+// "San Jose, CA" has a city and a region, and only destinations of four or
+// more parts have a street made of the leading ones and a country.
+func parseDestination(destination string) *pb.Address {
+	var parts []string
+	for _, part := range strings.Split(destination, ",") {
+		if part = collapseSpaces(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	a := &pb.Address{}
+	switch n := len(parts); {
+	case n == 1:
+		a.City = parts[0]
+	case n == 2:
+		a.City = parts[0]
+		a.Region, a.PostalCode = splitPostalCode(parts[1])
+	case n == 3:
+		a.Street, a.City = parts[0], parts[1]
+		a.Region, a.PostalCode = splitPostalCode(parts[2])
+	case n >= 4:
+		a.Street, a.City = strings.Join(parts[:n-3], ", "), parts[n-3]
+		a.Region, a.PostalCode = splitPostalCode(parts[n-2])
+		a.Country = parts[n-1]
+	}
+	return a
+}
+
+// splitPostalCode splits "CA 95134" into its region and postal code. The
+// postal code is the last word, if it has a digit.
+func splitPostalCode(s string) (region, postalCode string) {
+	words := strings.Fields(s)
+	last := words[len(words)-1]
+	if strings.IndexFunc(last, unicode.IsDigit) < 0 {
+		return s, ""
+	}
+	return strings.Join(words[:len(words)-1], " "), last
+}
+
+// formatAddress renders a as a destination, e.g. "1 Main St, San Jose, CA 95134, US".
+func formatAddress(a *pb.Address) string {
+	var parts []string
+	for _, part := range []string{a.Street, a.City, collapseSpaces(a.Region + " " + a.PostalCode), a.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// addressKey returns the key under which addresses that only differ in
+// case or whitespace are the same, e.g. to combine their shipments.
+func addressKey(a *pb.Address) string {
+	a = normalizeAddress(a)
+	return strings.ToLower(strings.Join([]string{a.Street, a.City, a.Region, a.PostalCode, a.Country}, "\x00"))
+}
+
+// orderAddress returns the normalized address of ord, parsed from its
+// destination for orders stored without address.
+func orderAddress(ord *pb.Order) *pb.Address {
+	if ord.Address != nil {
+		return normalizeAddress(ord.Address)
+	}
+	return normalizeAddress(parseDestination(ord.Destination))
+}
+
+// resolveAddress fills in the destination of an order about to be written
+// from its address or, for a legacy order, the address from its
+// destination, which is kept as sent.
+func resolveAddress(ord *pb.Order) {
+	legacy := ord.Address == nil
+	ord.Address = orderAddress(ord)
+	if !legacy {
+		ord.Destination = formatAddress(ord.Address)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	pb "ecommerce/order/proto"
+	"testing"
+)
+
+func TestParseDestination(t *testing.T) {
+	tests := []struct {
+		destination string
+		want        string // formatted address
+	}{
+		{"San Jose", "San Jose"},
+		{" San  Jose ,ca ", "San Jose, CA"},
+		{"San Jose, CA 95134", "San Jose, CA 95134"},
+		{"1 Main St, San Jose, CA", "1 Main St, San Jose, CA"},
+		{"Apt 4, 1 Main St, San Jose, ca 95134, us", "Apt 4, 1 Main St, San Jose, CA 95134, US"},
+	}
+	for _, test := range tests {
+		if got := formatAddress(normalizeAddress(parseDestination(test.destination))); got != test.want {
+			t.Errorf("parseDestination(%q) formats as %q, want %q", test.destination, got, test.want)
+		}
+	}
+}
+
+func TestAddressKey(t *testing.T) {
+	a := &pb.Address{Street: "1 Main St", City: "San Jose", Region: "CA", PostalCode: "95134", Country: "US"}
+	same := &pb.Address{Street: " 1  main st", City: "SAN JOSE", Region: "ca", PostalCode: "95134 ", Country: "us"}
+	other := &pb.Address{Street: "2 Main St", City: "San Jose", Region: "CA", PostalCode: "95134", Country: "US"}
+	if addressKey(a) != addressKey(same) {
+		t.Errorf("%v and %v have different keys", a, same)
+	}
+	if addressKey(a) == addressKey(other) {
+		t.Errorf("%v and %v have the same key", a, other)
+	}
+}
+
+func TestProcessOrdersCombinesNormalizedAddresses(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	for id, address := range map[string]*pb.Address{
+		"o1": {Street: "1 Main St", City: "San Jose", Region: "CA", Country: "US"},
+		"o2": {Street: "1  main st ", City: "san jose", Region: "ca", Country: "us"},
+	} {
+		ord := newOrder(id, "")
+		ord.Address = address
+		if _, err := client.AddOrder(context.Background(), ord); err != nil {
+			t.Fatalf("addOrder %v: %v", id, err)
+		}
+	}
+	// A legacy order to the same place, by destination only.
+	placeOrders(t, client, 400, "o3", "1 Main St, San Jose, CA, US")
+
+	send, _, closeSend := processOrders(t, client)
+	send("o1", "o2", "o3")
+	checkResults(t, closeSend(), "1 Main St, San Jose, CA, US: o1,o2,o3")
+}
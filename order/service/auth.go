@@ -0,0 +1,22 @@
+package main
+
+import (
+	"ecommerce/auth"
+	pb "ecommerce/order/proto"
+)
+
+// orderPolicy lets any authenticated caller place, read and follow orders,
+// and only the warehouse move them through fulfilment.
+var orderPolicy = auth.NewPolicy(pb.OrderManagement_ServiceDesc, map[string][]string{
+	"addOrder":        nil,
+	"getOrder":        nil,
+	"searchOrders":    nil,
+	"updateOrders":    nil,
+	"cancelOrder":     nil,
+	"processOrders":   {auth.RoleWarehouse},
+	"transitionOrder": {auth.RoleWarehouse},
+	"getShipment":     nil,
+	"listShipments":   nil,
+	"trackShipment":   nil,
+	"watchOrders":     nil,
+})
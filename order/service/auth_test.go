@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"ecommerce/auth"
+	pb "ecommerce/order/proto"
+	"encoding/json"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// incomingStream is a grpc.ServerStream of a request with ctx.
+type incomingStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s incomingStream) Context() context.Context { return s.ctx }
+
+func TestOrderPolicy(t *testing.T) {
+	keys := filepath.Join(t.TempDir(), "keys.json")
+	b, err := json.Marshal(map[string][]map[string]interface{}{"keys": {
+		{"key": "customer-key", "subject": "customer"},
+		{"key": "warehouse-key", "subject": "warehouse", "roles": []string{auth.RoleWarehouse}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keys, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := (&auth.Server{APIKeysFile: keys}).Authenticator()
+	if err != nil {
+		t.Fatalf("Authenticator: %v", err)
+	}
+	warehouseOnly := map[string]bool{"processOrders": true, "transitionOrder": true}
+
+	// call runs the interceptor of the method for a request sending token,
+	// and returns the code it fails with, or OK if it lets the call through.
+	unary, stream := a.UnaryServerInterceptor(orderPolicy), a.StreamServerInterceptor(orderPolicy)
+	call := func(method string, isStream bool, token string) codes.Code {
+		ctx := context.Background()
+		if token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+		}
+		fullMethod := "/" + pb.OrderManagement_ServiceDesc.ServiceName + "/" + method
+		var err error
+		if isStream {
+			err = stream(nil, incomingStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: fullMethod},
+				func(interface{}, grpc.ServerStream) error { return nil })
+		} else {
+			_, err = unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethod},
+				func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		}
+		return status.Code(err)
+	}
+	check := func(method string, isStream bool) {
+		t.Helper()
+		want := map[string]codes.Code{"": codes.Unauthenticated, "bad-key": codes.Unauthenticated, "customer-key": codes.OK, "warehouse-key": codes.OK}
+		if warehouseOnly[method] {
+			want["customer-key"] = codes.PermissionDenied
+		}
+		for token, code := range want {
+			if got := call(method, isStream, token); got != code {
+				t.Errorf("%v with token %q = %v, want %v", method, token, got, code)
+			}
+		}
+	}
+	for _, m := range pb.OrderManagement_ServiceDesc.Methods {
+		check(m.MethodName, false)
+	}
+	for _, s := range pb.OrderManagement_ServiceDesc.Streams {
+		check(s.StreamName, true)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	"ecommerce/rpcerr"
+	"fmt"
+	moneypb "google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/grpc/metadata"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request metadata keys a ProcessOrders caller uses to pick its batching policy.
+const (
+	batchPolicyKey   = "batch-policy"    // count (default), window, destination or value
+	batchSizeKey     = "batch-size"      // orders per batch for count, per shipment for destination
+	batchWindowKey   = "batch-window"    // flush interval for window, e.g. "500ms"
+	batchMaxValueKey = "batch-max-value" // shipment total, in its own currency, that triggers a flush for value
+)
+
+type batchKind string
+
+const (
+	// batchByCount flushes every pending shipment after size received orders.
+	batchByCount batchKind = "count"
+	// batchByWindow flushes every pending shipment once per window.
+	batchByWindow batchKind = "window"
+	// batchByDestination flushes a shipment once it holds size orders.
+	batchByDestination batchKind = "destination"
+	// batchByValue flushes a shipment once its total reaches maxValue,
+	// an amount applied to shipments of any currency.
+	batchByValue batchKind = "value"
+)
+
+// batchPolicy decides when ProcessOrders sends out the shipments it combines.
+// Whatever the policy, shipments still pending at the end of the stream are flushed.
+type batchPolicy struct {
+	kind     batchKind
+	size     int
+	window   time.Duration
+	maxValue *moneypb.Money // currency-less, see batchByValue
+}
+
+func (p batchPolicy) String() string {
+	switch p.kind {
+	case batchByWindow:
+		return fmt.Sprintf("%v(%v)", p.kind, p.window)
+	case batchByValue:
+		return fmt.Sprintf("%v(%v)", p.kind, strings.TrimSpace(money.Format(p.maxValue)))
+	}
+	return fmt.Sprintf("%v(%v)", p.kind, p.size)
+}
+
+// batchPolicyFromContext reads the policy negotiated in the request metadata,
+// defaulting to flushing everything every orderBatchSize orders.
+func batchPolicyFromContext(ctx context.Context) (batchPolicy, error) {
+	p := batchPolicy{kind: batchByCount, size: orderBatchSize}
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	if kind := get(batchPolicyKey); kind != "" {
+		p.kind = batchKind(kind)
+	}
+	if v := get(batchSizeKey); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size <= 0 {
+			return p, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, batchSizeKey, "%v must be a positive integer, got %q", batchSizeKey, v)
+		}
+		p.size = size
+	}
+
+	switch p.kind {
+	case batchByCount, batchByDestination:
+	case batchByWindow:
+		window, err := time.ParseDuration(get(batchWindowKey))
+		if err != nil || window <= 0 {
+			return p, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, batchWindowKey, "%v policy needs a positive %v duration", batchByWindow, batchWindowKey)
+		}
+		p.window = window
+	case batchByValue:
+		maxValue, err := money.Parse("", get(batchMaxValueKey))
+		if err != nil || money.Sign(maxValue) <= 0 {
+			return p, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, batchMaxValueKey, "%v policy needs a positive %v", batchByValue, batchMaxValueKey)
+		}
+		p.maxValue = maxValue
+	default:
+		return p, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, batchPolicyKey, "unknown %v %q", batchPolicyKey, p.kind)
+	}
+	return p, nil
+}
+
+// afterAdd returns the shipments to send now that an order was added to the
+// shipment under key, received being the number of orders received so far.
+func (p batchPolicy) afterAdd(batch *shipmentBatch, key string, received int) []*pb.CombinedShipment {
+	switch p.kind {
+	case batchByCount:
+		if received%p.size == 0 {
+			return batch.drain()
+		}
+	case batchByDestination:
+		if len(batch.shipments[key].OrdersList) >= p.size {
+			return []*pb.CombinedShipment{batch.take(key)}
+		}
+	case batchByValue:
+		total := batch.shipments[key].Total
+		threshold := money.New(total.CurrencyCode, p.maxValue.Units, p.maxValue.Nanos)
+		if money.Compare(total, threshold) >= 0 {
+			return []*pb.CombinedShipment{batch.take(key)}
+		}
+	}
+	return nil
+}
+
+// ticker returns the channel driving time based flushes, nil if the policy has none.
+func (p batchPolicy) ticker() (<-chan time.Time, func()) {
+	if p.kind != batchByWindow {
+		return nil, func() {}
+	}
+	t := time.NewTicker(p.window)
+	return t.C, t.Stop
+}
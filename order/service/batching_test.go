@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	mountainView = "Mountain View, CA"
+	sanJose      = "San Jose, CA"
+)
+
+// placeOrders adds an order worth price USD for each id, shipped to the
+// destination following it, e.g. placeOrders(t, client, 400, "o1", sanJose).
+func placeOrders(t *testing.T, client pb.OrderManagementClient, price int64, idsAndDestinations ...string) {
+	t.Helper()
+	for i := 0; i < len(idsAndDestinations); i += 2 {
+		ord := newOrder(idsAndDestinations[i], idsAndDestinations[i+1])
+		ord.Price = money.New("USD", price, 0)
+		if _, err := client.AddOrder(context.Background(), ord); err != nil {
+			t.Fatalf("addOrder %v: %v", idsAndDestinations[i], err)
+		}
+	}
+}
+
+// describe renders a result as "Destination: id,id" for a shipment and
+// "rejected id: Code" for a rejection.
+func describe(res *pb.ProcessResult) string {
+	if rej := res.GetRejection(); rej != nil {
+		return fmt.Sprintf("rejected %v: %v", rej.OrderId, codes.Code(rej.Status.Code))
+	}
+	shipment := res.GetShipment()
+	ids := make([]string, len(shipment.OrdersList))
+	for i, ord := range shipment.OrdersList {
+		ids[i] = ord.Id
+	}
+	return fmt.Sprintf("%v: %v", shipment.Destination, strings.Join(ids, ","))
+}
+
+// processOrders opens a processOrders stream with the batching metadata
+// keysAndValues. It returns functions sending ids, receiving the next n
+// results, and closing the stream to receive the results left.
+func processOrders(t *testing.T, client pb.OrderManagementClient, keysAndValues ...string) (send func(ids ...string), recv func(n int) []string, closeSend func() []string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	stream, err := client.ProcessOrders(metadata.AppendToOutgoingContext(ctx, keysAndValues...))
+	if err != nil {
+		t.Fatalf("processOrders: %v", err)
+	}
+	send = func(ids ...string) {
+		t.Helper()
+		for _, id := range ids {
+			if err := stream.Send(&pb.OrderId{Id: id}); err != nil {
+				t.Fatalf("send %v: %v", id, err)
+			}
+		}
+	}
+	recv = func(n int) []string {
+		t.Helper()
+		var results []string
+		for len(results) < n {
+			res, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("recv after %q: %v", results, err)
+			}
+			results = append(results, describe(res))
+		}
+		return results
+	}
+	closeSend = func() []string {
+		t.Helper()
+		if err := stream.CloseSend(); err != nil {
+			t.Fatalf("close send: %v", err)
+		}
+		var results []string
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return results
+			}
+			if err != nil {
+				t.Fatalf("recv after %q: %v", results, err)
+			}
+			results = append(results, describe(res))
+		}
+	}
+	return send, recv, closeSend
+}
+
+func checkResults(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got results\n\t%v\nwant\n\t%v", strings.Join(got, "\n\t"), strings.Join(want, "\n\t"))
+	}
+}
+
+func TestProcessOrdersByCount(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	placeOrders(t, client, 400, "o1", mountainView, "o2", sanJose, "o3", mountainView, "o4", mountainView, "o5", sanJose)
+	send, recv, closeSend := processOrders(t, client, batchPolicyKey, "count", batchSizeKey, "2")
+
+	// Every pending shipment goes out after each second order, ordered by
+	// destination, with its orders in the order they were received.
+	send("o1", "o2")
+	checkResults(t, recv(2), mountainView+": o1", sanJose+": o2")
+	send("o3", "o4")
+	checkResults(t, recv(1), mountainView+": o3,o4")
+	// The remaining order goes out at the end of the stream.
+	send("o5")
+	checkResults(t, closeSend(), sanJose+": o5")
+}
+
+func TestProcessOrdersByDestination(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	placeOrders(t, client, 400, "o1", mountainView, "o2", sanJose, "o3", mountainView, "o4", sanJose, "o5", mountainView)
+	send, recv, closeSend := processOrders(t, client, batchPolicyKey, "destination", batchSizeKey, "2")
+
+	// A shipment goes out as soon as it holds two orders.
+	send("o1", "o2", "o3")
+	checkResults(t, recv(1), mountainView+": o1,o3")
+	send("o4")
+	checkResults(t, recv(1), sanJose+": o2,o4")
+	send("o5")
+	checkResults(t, closeSend(), mountainView+": o5")
+}
+
+func TestProcessOrdersByValue(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	placeOrders(t, client, 400, "o1", mountainView, "o3", sanJose)
+	placeOrders(t, client, 300, "o2", mountainView, "o4", mountainView)
+	send, recv, closeSend := processOrders(t, client, batchPolicyKey, "value", batchMaxValueKey, "700")
+
+	// A shipment goes out once it is worth 700 or more.
+	send("o1", "o2")
+	checkResults(t, recv(1), mountainView+": o1,o2")
+	send("o3", "o4")
+	checkResults(t, closeSend(), mountainView+": o4", sanJose+": o3")
+}
+
+func TestProcessOrdersByWindow(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	placeOrders(t, client, 400, "o1", mountainView, "o2", sanJose, "o3", mountainView)
+	send, recv, closeSend := processOrders(t, client, batchPolicyKey, "window", batchWindowKey, "50ms")
+
+	// The shipments go out on the next tick, while the stream is still open.
+	send("o1", "o2")
+	checkResults(t, recv(2), mountainView+": o1", sanJose+": o2")
+	send("o3")
+	checkResults(t, recv(1), mountainView+": o3")
+	checkResults(t, closeSend())
+}
+
+func TestProcessOrdersRejectsBadPolicy(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	for _, md := range [][]string{
+		{batchPolicyKey, "weekly"},
+		{batchPolicyKey, "count", batchSizeKey, "0"},
+		{batchPolicyKey, "window"},
+		{batchPolicyKey, "value", batchMaxValueKey, "-1"},
+	} {
+		stream, err := client.ProcessOrders(metadata.AppendToOutgoingContext(context.Background(), md...))
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("processOrders with %q = %v, want InvalidArgument", md, err)
+		}
+	}
+}
+
+func TestProcessOrdersFlushesAtEndOfStream(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	placeOrders(t, client, 400, "o1", sanJose, "o2", mountainView, "o3", sanJose)
+	send, _, closeSend := processOrders(t, client) // three orders per batch
+
+	// Nothing is due before the end of the stream, which then flushes every
+	// shipment, ordered by destination.
+	send("o1", "o2")
+	checkResults(t, closeSend(), mountainView+": o2", sanJose+": o1")
+
+	// Orders shipped are linked to their shipment and no longer pending.
+	for _, id := range []string{"o1", "o2"} {
+		ord, err := client.GetOrder(context.Background(), &pb.OrderId{Id: id})
+		if err != nil {
+			t.Fatalf("getOrder %v: %v", id, err)
+		}
+		if ord.Status != pb.OrderStatus_ORDER_STATUS_PROCESSING || ord.ShipmentId == "" {
+			t.Errorf("order %v is %v in shipment %q, want PROCESSING in a shipment", id, ord.Status, ord.ShipmentId)
+		}
+	}
+	if ord, err := client.GetOrder(context.Background(), &pb.OrderId{Id: "o3"}); err != nil || ord.Status != pb.OrderStatus_ORDER_STATUS_PENDING {
+		t.Errorf("order o3 is %v, %v, want PENDING", ord.GetStatus(), err)
+	}
+}
+
+func TestProcessOrdersRejectsInvalidIds(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	placeOrders(t, client, 400, "o1", mountainView, "o2", mountainView, "o3", mountainView, "o4", mountainView)
+	if _, err := client.CancelOrder(context.Background(), &pb.OrderId{Id: "o3"}); err != nil {
+		t.Fatalf("cancelOrder: %v", err)
+	}
+	send, _, closeSend := processOrders(t, client, batchPolicyKey, "count", batchSizeKey, "2")
+
+	// Rejected ids are reported in stream order, between the shipments, and
+	// do not count towards a batch.
+	send("o1", "", "o2", "missing", "o3", "o4")
+	checkResults(t, closeSend(),
+		"rejected : InvalidArgument",
+		mountainView+": o1,o2",
+		"rejected missing: NotFound",
+		"rejected o3: FailedPrecondition",
+		mountainView+": o4",
+	)
+}
+
+// uncommittableStock is a Stock whose reservations cannot be committed.
+type uncommittableStock struct{ *fakeStock }
+
+func (uncommittableStock) Commit(_ context.Context, id string) error {
+	return status.Errorf(codes.FailedPrecondition, "reservation %v expired", id)
+}
+
+func TestProcessOrdersCountsOnlyBatchedOrders(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, uncommittableStock{newFakeStock()})
+	placeOrders(t, client, 400, "o1", sanJose, "o2", sanJose, "o4", sanJose)
+	if _, err := client.AddOrder(context.Background(), lineItemOrder("o3", 1)); err != nil {
+		t.Fatalf("addOrder: %v", err)
+	}
+	send, _, closeSend := processOrders(t, client, batchPolicyKey, "count", batchSizeKey, "2")
+
+	// Neither an order received twice nor one whose stock cannot be committed
+	// joins the batch or counts towards it.
+	send("o1", "o1", "o3", "o2", "o4")
+	checkResults(t, closeSend(),
+		"rejected o1: FailedPrecondition",
+		"rejected o3: FailedPrecondition",
+		sanJose+": o1,o2",
+		sanJose+": o4",
+	)
+	if ord, err := client.GetOrder(context.Background(), &pb.OrderId{Id: "o3"}); err != nil || ord.Status != pb.OrderStatus_ORDER_STATUS_PENDING {
+		t.Errorf("order o3 is %v, %v, want PENDING", ord.GetStatus(), err)
+	}
+}
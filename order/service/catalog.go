@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	productpb "ecommerce/product/proto"
+	"ecommerce/rpcerr"
+	"errors"
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// productService names the ProductInfo service in dependency errors.
+const productService = "product.ProductInfo"
+
+// Catalog looks up the products that order line items refer to.
+type Catalog interface {
+	// Product returns the product with the given id. It fails with
+	// codes.NotFound if there is none, and with another code if the
+	// catalog could not be consulted.
+	Product(ctx context.Context, id string) (*productpb.Product, error)
+}
+
+// productCatalog is the Catalog served by the ProductInfo service.
+type productCatalog struct {
+	client productpb.ProductInfoClient
+}
+
+func (c *productCatalog) Product(ctx context.Context, id string) (*productpb.Product, error) {
+	return c.client.GetProduct(ctx, &productpb.ProductID{Value: id})
+}
+
+// priceOrder resolves the line items of ord against the catalog: it fills
+// in their names and unit prices, sets ord.Items to the product names and
+// ord.Price to the order total. Orders without line items are left as is.
+//
+// It fails with InvalidArgument if a product does not exist or is priced in
+// another currency than the first one, listing every such line item, and
+// with a dependency error if the catalog is unavailable.
+func priceOrder(ctx context.Context, catalog Catalog, ord *pb.Order) error {
+	if len(ord.LineItems) == 0 {
+		return nil
+	}
+
+	products := make(map[string]*productpb.Product, len(ord.LineItems))
+	var unknown []*errdetails.BadRequest_FieldViolation
+	for i, item := range ord.LineItems {
+		if _, seen := products[item.ProductId]; seen {
+			continue
+		}
+		product, err := catalog.Product(ctx, item.ProductId)
+		switch {
+		case status.Code(err) == codes.NotFound:
+			products[item.ProductId] = nil
+			unknown = append(unknown, &errdetails.BadRequest_FieldViolation{
+				Field:       fmt.Sprintf("line_items[%d].product_id", i),
+				Description: fmt.Sprintf("unknown product %v", item.ProductId),
+			})
+		case err != nil:
+			return rpcerr.DependencyFailed(productService, err)
+		default:
+			products[item.ProductId] = product
+		}
+	}
+	if len(unknown) > 0 {
+		return rpcerr.BadRequest(rpcerr.ReasonUnknownProduct, fmt.Sprintf("Order %v refers to %d unknown product(s)", ord.Id, len(unknown)), unknown...).Err()
+	}
+
+	currency := products[ord.LineItems[0].ProductId].Price.GetCurrencyCode()
+	total := money.New(currency, 0, 0)
+	items := make([]string, len(ord.LineItems))
+	var mismatched []*errdetails.BadRequest_FieldViolation
+	for i, item := range ord.LineItems {
+		product := products[item.ProductId]
+		item.Name = product.Name
+		item.UnitPrice = product.Price
+		items[i] = product.Name
+
+		amount, err := money.Multiply(product.Price, int64(item.Quantity))
+		if err == nil {
+			total, err = money.Add(total, amount)
+		}
+		switch {
+		case errors.Is(err, money.ErrCurrencyMismatch):
+			mismatched = append(mismatched, &errdetails.BadRequest_FieldViolation{
+				Field:       fmt.Sprintf("line_items[%d].product_id", i),
+				Description: fmt.Sprintf("product %v is priced in %v, the order in %v", item.ProductId, product.Price.GetCurrencyCode(), currency),
+			})
+		case err != nil:
+			return rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, fmt.Sprintf("line_items[%d].quantity", i), "Order %v total: %v", ord.Id, err)
+		}
+	}
+	if len(mismatched) > 0 {
+		return rpcerr.BadRequest(rpcerr.ReasonCurrencyMismatch, fmt.Sprintf("Order %v mixes currencies", ord.Id), mismatched...).Err()
+	}
+
+	ord.Items = items
+	ord.Price = total
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	productpb "ecommerce/product/proto"
+	"ecommerce/rpcerr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+// unavailableCatalog is a Catalog whose product service is down.
+type unavailableCatalog struct{}
+
+func (unavailableCatalog) Product(context.Context, string) (*productpb.Product, error) {
+	return nil, status.Error(codes.Unavailable, "connection refused")
+}
+
+func TestAddOrderPricesLineItems(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	ctx := context.Background()
+	id, err := client.AddOrder(ctx, &pb.Order{
+		Destination: sanJose,
+		Items:       []string{"ignored"},
+		Price:       money.New("USD", 1, 0), // ignored
+		LineItems:   []*pb.LineItem{{ProductId: "p1", Quantity: 2}, {ProductId: "p2", Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("addOrder: %v", err)
+	}
+	ord, err := client.GetOrder(ctx, id)
+	if err != nil {
+		t.Fatalf("getOrder: %v", err)
+	}
+	if got := money.Format(ord.Price); got != money.Format(money.New("USD", 1100, 0)) {
+		t.Errorf("price = %v, want 1100 USD", got)
+	}
+	if len(ord.Items) != 2 || ord.Items[0] != "Google Pixel 3A" || ord.Items[1] != "Apple Watch S4" {
+		t.Errorf("items = %q, want the product names", ord.Items)
+	}
+	for _, item := range ord.LineItems {
+		if want := testCatalog[item.ProductId]; item.Name != want.Name || money.Compare(item.UnitPrice, want.Price) != 0 {
+			t.Errorf("line item %v is %q at %v, want %q at %v", item.ProductId, item.Name, money.Format(item.UnitPrice), want.Name, money.Format(want.Price))
+		}
+	}
+}
+
+func TestAddOrderRejectsUnpriceableLineItems(t *testing.T) {
+	catalog := fakeCatalog{
+		"p1": testCatalog["p1"],
+		"p3": {Id: "p3", Name: "Nokia 3310", Price: money.New("EUR", 50, 0)},
+	}
+	tests := []struct {
+		name    string
+		catalog Catalog
+		items   []*pb.LineItem
+		code    codes.Code
+		reason  string
+	}{
+		{"unknown product", catalog, []*pb.LineItem{{ProductId: "p1", Quantity: 1}, {ProductId: "missing", Quantity: 1}},
+			codes.InvalidArgument, rpcerr.ReasonUnknownProduct},
+		{"mixed currencies", catalog, []*pb.LineItem{{ProductId: "p1", Quantity: 1}, {ProductId: "p3", Quantity: 1}},
+			codes.InvalidArgument, rpcerr.ReasonCurrencyMismatch},
+		{"catalog unavailable", unavailableCatalog{}, []*pb.LineItem{{ProductId: "p1", Quantity: 1}},
+			codes.Unavailable, rpcerr.ReasonDependencyFailed},
+	}
+	for _, test := range tests {
+		client := startServer(t, newMemOrderStore(), test.catalog, newFakeStock())
+		_, err := client.AddOrder(context.Background(), &pb.Order{Destination: sanJose, LineItems: test.items})
+		if status.Code(err) != test.code || rpcerr.Reason(err) != test.reason {
+			t.Errorf("%v: addOrder = %v (%v), want %v (%v)", test.name, err, rpcerr.Reason(err), test.code, test.reason)
+		}
+	}
+}
+
+func TestUpdateOrdersKeepsPlacedPrices(t *testing.T) {
+	catalog := fakeCatalog{"p1": testCatalog["p1"]}
+	client := startServer(t, newMemOrderStore(), catalog, newFakeStock())
+	ctx := context.Background()
+	check := func(id string, price int64, unitPrice int64, name string) {
+		t.Helper()
+		ord, err := client.GetOrder(ctx, &pb.OrderId{Id: id})
+		if err != nil {
+			t.Fatalf("getOrder %v: %v", id, err)
+		}
+		item := ord.LineItems[0]
+		if money.Compare(ord.Price, money.New("USD", price, 0)) != 0 || money.Compare(item.UnitPrice, money.New("USD", unitPrice, 0)) != 0 ||
+			item.Name != name || len(ord.Items) != 1 || ord.Items[0] != name {
+			t.Errorf("order %v is %v for %q at %v each, want %v USD for %q at %v USD", id, money.Format(ord.Price), ord.Items, money.Format(item.UnitPrice), price, name, unitPrice)
+		}
+	}
+	if _, err := client.AddOrder(ctx, lineItemOrder("o1", 2)); err != nil {
+		t.Fatalf("addOrder: %v", err)
+	}
+	check("o1", 800, 400, "Google Pixel 3A")
+
+	// The catalog changes after the order was placed.
+	catalog["p1"] = &productpb.Product{Id: "p1", Name: "Google Pixel 3A (2020)", Price: money.New("USD", 500, 0)}
+	for _, atomic := range []bool{false, true} {
+		update := lineItemOrder("o1", 2)
+		update.Description = "gift"
+		update.Items = []string{"ignored"}
+		update.Price = money.New("USD", 1, 0) // ignored
+		checkResults(t, updateOrders(t, client, atomic, update), "o1 UPDATE_OUTCOME_UPDATED")
+		check("o1", 800, 400, "Google Pixel 3A")
+	}
+
+	// Orders created by updateOrders are priced from the catalog as it is now.
+	checkResults(t, updateOrders(t, client, false, lineItemOrder("o2", 2)), "o2 UPDATE_OUTCOME_CREATED")
+	check("o2", 1000, 500, "Google Pixel 3A (2020)")
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"ecommerce/resilience"
+	"google.golang.org/grpc"
+	"log"
+)
+
+// dialDependency connects to service, another service of this repo, at addr.
+// Every call the order service makes to another service is idempotent, so
+// calls are retried and circuit broken as configured by the dependency-*
+// flags, each service getting a breaker of its own. The dependency-tls-*
+// flags secure the connection, and the token of the dependency-token* flags
+// or $ECOMMERCE_DEPENDENCY_TOKEN authenticates the order service to the
+// other services.
+func dialDependency(service, addr string) (*grpc.ClientConn, error) {
+	creds, err := dependencyTLS.Credentials()
+	if err != nil {
+		return nil, err
+	}
+	policy := resilience.DefaultPolicy()
+	policy.Timeout = *dependencyTimeout
+	policy.MaxAttempts = *dependencyAttempts
+	policy.Breaker = resilience.NewBreaker(*dependencyBreakerFailures, *dependencyBreakerCooldown)
+	policy.Breaker.OnStateChange = func(state string) {
+		log.Printf("%v [%v] circuit breaker %v\n", tag, service, state)
+	}
+	authOpts, err := dependencyAuth.DialOptions(dependencyTLS.Enabled())
+	if err != nil {
+		return nil, err
+	}
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUnaryInterceptor(resilience.UnaryClientInterceptor(policy)),
+	}
+	return grpc.Dial(addr, append(opts, authOpts...)...)
+}
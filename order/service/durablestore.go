@@ -0,0 +1,122 @@
+package main
+
+import (
+	pb "ecommerce/order/proto"
+	"ecommerce/storage"
+	"google.golang.org/protobuf/proto"
+	"sync"
+)
+
+// durableOrderStore is an OrderStore backed by the embedded storage engine.
+// Writes are fsynced to the write-ahead log before they are acknowledged,
+// reads are served from the decoded copy kept in memory.
+type durableOrderStore struct {
+	// mu keeps the log and the in-memory copy in the same write order.
+	mu  sync.Mutex
+	db  *storage.DB
+	mem *memOrderStore
+}
+
+func openDurableOrderStore(dir string) (*durableOrderStore, error) {
+	db, err := storage.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &durableOrderStore{db: db, mem: newMemOrderStore()}
+	db.Range(func(_ string, value []byte) bool {
+		ord := &pb.Order{}
+		if err = proto.Unmarshal(value, ord); err != nil {
+			return false
+		}
+		migrateOrder(ord)
+		d.mem.orders[ord.Id] = ord
+		return true
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *durableOrderStore) Get(id string) (*pb.Order, bool) {
+	return d.mem.Get(id)
+}
+
+func (d *durableOrderStore) Put(order *pb.Order) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.put(order)
+}
+
+func (d *durableOrderStore) put(order *pb.Order) error {
+	b, err := proto.Marshal(order)
+	if err != nil {
+		return err
+	}
+	if err := d.db.Put(order.Id, b); err != nil {
+		return err
+	}
+	return d.mem.Put(order)
+}
+
+func (d *durableOrderStore) Update(id string, fn func(current *pb.Order) (*pb.Order, error)) (*pb.Order, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current, _ := d.mem.Get(id)
+	updated, err := fn(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.put(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (d *durableOrderStore) UpdateAll(ids []string, fn func(current map[string]*pb.Order) ([]*pb.Order, error)) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.mem.mu.RLock()
+	current := d.mem.lookupLocked(ids)
+	d.mem.mu.RUnlock()
+	updated, err := fn(current)
+	if err != nil {
+		return err
+	}
+
+	batch := &storage.Batch{}
+	for _, ord := range updated {
+		b, err := proto.Marshal(ord)
+		if err != nil {
+			return err
+		}
+		batch.Put(ord.Id, b)
+	}
+	if err := d.db.Write(batch); err != nil {
+		return err
+	}
+	return d.mem.UpdateAll(nil, func(map[string]*pb.Order) ([]*pb.Order, error) { return updated, nil })
+}
+
+func (d *durableOrderStore) List() []*pb.Order {
+	return d.mem.List()
+}
+
+func (d *durableOrderStore) Delete(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.db.Delete(id); err != nil {
+		return err
+	}
+	return d.mem.Delete(id)
+}
+
+func (d *durableOrderStore) Scan(fn func(order *pb.Order) bool) {
+	d.mem.Scan(fn)
+}
+
+func (d *durableOrderStore) Close() error {
+	return d.db.Close()
+}
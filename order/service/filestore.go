@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	pb "ecommerce/order/proto"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protojson"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileOrderStore is an OrderStore that mirrors its in-memory map to a file,
+// one JSON encoded order per line. The whole file is rewritten on every
+// mutation through a temp file + rename so a crash never leaves it half written,
+// and the map only changes once the file has.
+type fileOrderStore struct {
+	// mu serializes writers so the file always reflects the latest write.
+	mu   sync.Mutex
+	path string
+	mem  *memOrderStore
+}
+
+func openFileOrderStore(path string) (*fileOrderStore, error) {
+	f := &fileOrderStore{path: path, mem: newMemOrderStore()}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *fileOrderStore) load() error {
+	file, err := os.Open(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		ord := &pb.Order{}
+		if err := protojson.Unmarshal(scanner.Bytes(), ord); err != nil {
+			return fmt.Errorf("%v:%d: %w", f.path, line, err)
+		}
+		migrateOrder(ord)
+		f.mem.orders[ord.Id] = ord
+	}
+	return scanner.Err()
+}
+
+// flush writes orders to the file, replacing its contents. The temp file is
+// synced before the rename and the directory after it, so that once flush
+// returns the file holds orders even across a power loss.
+func (f *fileOrderStore) flush(orders []*pb.Order) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, ord := range orders {
+		b, err := protojson.Marshal(ord)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		if _, err := w.Write(append(b, '\n')); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(f.path))
+}
+
+// next returns the orders of the store as they are once the orders in put
+// are stored and the order with id deleted, if not empty, is removed. Writers
+// flush them before changing the map, so that the map never holds a write
+// the file does not.
+func (f *fileOrderStore) next(put []*pb.Order, deleted string) []*pb.Order {
+	f.mem.mu.RLock()
+	orders := make(map[string]*pb.Order, len(f.mem.orders)+len(put))
+	for id, ord := range f.mem.orders {
+		orders[id] = ord
+	}
+	f.mem.mu.RUnlock()
+	for _, ord := range put {
+		orders[ord.Id] = ord
+	}
+	delete(orders, deleted)
+
+	list := make([]*pb.Order, 0, len(orders))
+	for _, ord := range orders {
+		list = append(list, ord)
+	}
+	return list
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (f *fileOrderStore) Get(id string) (*pb.Order, bool) {
+	return f.mem.Get(id)
+}
+
+func (f *fileOrderStore) Put(order *pb.Order) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.flush(f.next([]*pb.Order{order}, "")); err != nil {
+		return err
+	}
+	return f.mem.Put(order)
+}
+
+func (f *fileOrderStore) Update(id string, fn func(current *pb.Order) (*pb.Order, error)) (*pb.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	current, _ := f.mem.Get(id)
+	updated, err := fn(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.flush(f.next([]*pb.Order{updated}, "")); err != nil {
+		return nil, err
+	}
+	f.mem.Put(updated)
+	return updated, nil
+}
+
+func (f *fileOrderStore) UpdateAll(ids []string, fn func(current map[string]*pb.Order) ([]*pb.Order, error)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mem.mu.RLock()
+	current := f.mem.lookupLocked(ids)
+	f.mem.mu.RUnlock()
+	updated, err := fn(current)
+	if err != nil {
+		return err
+	}
+	if err := f.flush(f.next(updated, "")); err != nil {
+		return err
+	}
+	return f.mem.UpdateAll(nil, func(map[string]*pb.Order) ([]*pb.Order, error) { return updated, nil })
+}
+
+func (f *fileOrderStore) List() []*pb.Order {
+	return f.mem.List()
+}
+
+func (f *fileOrderStore) Delete(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.mem.Get(id); !exists {
+		return nil
+	}
+	if err := f.flush(f.next(nil, id)); err != nil {
+		return err
+	}
+	return f.mem.Delete(id)
+}
+
+func (f *fileOrderStore) Scan(fn func(order *pb.Order) bool) {
+	f.mem.Scan(fn)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	pb "ecommerce/order/proto"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStoreKeepsMemoryInStepWithFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "orders.json")
+	f, err := openFileOrderStore(path)
+	if err != nil {
+		t.Fatalf("openFileOrderStore: %v", err)
+	}
+	for _, id := range []string{"o1", "o2"} {
+		if err := f.Put(newOrder(id, sanJose)); err != nil {
+			t.Fatalf("Put %v: %v", id, err)
+		}
+	}
+
+	// Writes that do not reach the file leave the store as it was.
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Put(newOrder("o3", sanJose)); err == nil {
+		t.Error("Put without a directory succeeded")
+	}
+	if _, err := f.Update("o1", func(*pb.Order) (*pb.Order, error) { return newOrder("o1", mountainView), nil }); err == nil {
+		t.Error("Update without a directory succeeded")
+	}
+	if err := f.UpdateAll([]string{"o2"}, func(map[string]*pb.Order) ([]*pb.Order, error) {
+		return []*pb.Order{newOrder("o2", mountainView), newOrder("o4", sanJose)}, nil
+	}); err == nil {
+		t.Error("UpdateAll without a directory succeeded")
+	}
+	if err := f.Delete("o2"); err == nil {
+		t.Error("Delete without a directory succeeded")
+	}
+	check := func(s OrderStore) {
+		t.Helper()
+		if n := len(s.List()); n != 2 {
+			t.Errorf("%d orders stored, want 2", n)
+		}
+		for _, id := range []string{"o1", "o2"} {
+			if ord, ok := s.Get(id); !ok || ord.Destination != sanJose {
+				t.Errorf("order %v = %v, want it shipped to %v", id, ord, sanJose)
+			}
+		}
+	}
+	check(f)
+
+	// Once the file can be written again, it holds the orders as stored.
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Put(newOrder("o1", sanJose)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	reopened, err := openFileOrderStore(path)
+	if err != nil {
+		t.Fatalf("openFileOrderStore: %v", err)
+	}
+	check(reopened)
+}
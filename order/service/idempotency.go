@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"ecommerce/auth"
+	pb "ecommerce/order/proto"
+	"ecommerce/rpcerr"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"sync"
+	"time"
+)
+
+const (
+	// idempotencyKeyHeader is the request metadata key clients set to make
+	// a retried addOrder return the order created by the first attempt.
+	// Keys are only remembered in memory, for idempotencyTTL: a retry after
+	// the service restarted creates the order again, or fails with
+	// AlreadyExists if the order has an id of its own.
+	idempotencyKeyHeader = "idempotency-key"
+	idempotencyTTL       = 24 * time.Hour
+)
+
+type idempotencyEntry struct {
+	done    chan struct{}
+	request [sha256.Size]byte // hash of the request that created the entry
+	orderId string
+	err     error
+	expires time.Time
+}
+
+// expired reports whether e is a completed entry past its expiry. Entries
+// still being created do not expire.
+func (e *idempotencyEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// idempotencyCache remembers which order was created for each idempotency
+// key, and by which request.
+type idempotencyCache struct {
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	ttl       time.Duration
+	lastPurge time.Time
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{entries: make(map[string]*idempotencyEntry), ttl: ttl}
+}
+
+// idempotencyKey returns the idempotency key of the request of ctx, scoped
+// to the authenticated caller so that callers cannot see each other's
+// orders through the same key, or "" if the request has none.
+func idempotencyKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	v := md.Get(idempotencyKeyHeader)
+	if len(v) == 0 || v[0] == "" {
+		return ""
+	}
+	subject := ""
+	if p, ok := auth.FromContext(ctx); ok {
+		subject = p.Subject
+	}
+	return subject + "\x00" + v[0]
+}
+
+// requestHash returns the hash identifying req among the requests sent with
+// the same idempotency key.
+func requestHash(req *pb.Order) ([sha256.Size]byte, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return [sha256.Size]byte{}, rpcerr.Internal("Hashing the request: %v", err)
+	}
+	return sha256.Sum256(b), nil
+}
+
+// do calls create at most once per key while the key is remembered. Callers
+// racing on the same key wait for the first one, or until ctx is done, and
+// share its result; request, the hash of their request, must match that of
+// the first one, or they fail with InvalidArgument. Failures are not
+// remembered, so a retry after an error calls create again.
+func (c *idempotencyCache) do(ctx context.Context, key string, request [sha256.Size]byte, create func() (string, error)) (string, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	c.purgeLocked(now)
+	// Expired keys are treated as unknown whether or not they were purged yet.
+	if e, ok := c.entries[key]; ok && !e.expired(now) {
+		c.mu.Unlock()
+		if e.request != request {
+			return "", rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, idempotencyKeyHeader,
+				"Idempotency key was already used for another request")
+		}
+		select {
+		case <-e.done:
+			return e.orderId, e.err
+		case <-ctx.Done():
+			return "", status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	e := &idempotencyEntry{done: make(chan struct{}), request: request}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	e.orderId, e.err = create()
+
+	c.mu.Lock()
+	if e.err != nil {
+		delete(c.entries, key)
+	} else {
+		e.expires = time.Now().Add(c.ttl)
+	}
+	c.mu.Unlock()
+	close(e.done)
+
+	return e.orderId, e.err
+}
+
+// purgeLocked drops expired keys, at most once a minute.
+func (c *idempotencyCache) purgeLocked(now time.Time) {
+	if now.Sub(c.lastPurge) < time.Minute {
+		return
+	}
+	c.lastPurge = now
+	for key, e := range c.entries {
+		if e.expired(now) {
+			delete(c.entries, key)
+		}
+	}
+}
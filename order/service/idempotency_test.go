@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"ecommerce/auth"
+	pb "ecommerce/order/proto"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testRequest is the hash of the requests of the tests.
+var testRequest = sha256.Sum256([]byte("request"))
+
+func TestIdempotencyCacheCreatesOncePerKey(t *testing.T) {
+	c := newIdempotencyCache(time.Hour)
+	var mu sync.Mutex
+	calls := 0
+	create := func() (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return "o1", nil
+	}
+
+	// Callers racing on a key share the order created by the first one.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if id, err := c.do(context.Background(), "k", testRequest, create); id != "o1" || err != nil {
+				t.Errorf("do = %v, %v, want o1", id, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("create called %d times, want once", calls)
+	}
+}
+
+func TestIdempotencyCacheForgetsFailures(t *testing.T) {
+	c := newIdempotencyCache(time.Hour)
+	failure := errors.New("unavailable")
+	if _, err := c.do(context.Background(), "k", testRequest, func() (string, error) { return "", failure }); err != failure {
+		t.Fatalf("do = %v, want %v", err, failure)
+	}
+	if id, err := c.do(context.Background(), "k", testRequest, func() (string, error) { return "o1", nil }); id != "o1" || err != nil {
+		t.Errorf("retry after a failure = %v, %v, want o1", id, err)
+	}
+}
+
+func TestIdempotencyCacheExpiresKeys(t *testing.T) {
+	c := newIdempotencyCache(time.Millisecond)
+	if _, err := c.do(context.Background(), "k", testRequest, func() (string, error) { return "o1", nil }); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// The key was not purged yet, a purge having just run, but has expired.
+	if _, kept := c.entries["k"]; !kept {
+		t.Fatal("key purged before the next purge is due")
+	}
+	if id, err := c.do(context.Background(), "k", testRequest, func() (string, error) { return "o2", nil }); id != "o2" || err != nil {
+		t.Errorf("do after expiry = %v, %v, want a new order o2", id, err)
+	}
+}
+
+func TestIdempotencyCacheRejectsOtherRequests(t *testing.T) {
+	c := newIdempotencyCache(time.Hour)
+	if _, err := c.do(context.Background(), "k", testRequest, func() (string, error) { return "o1", nil }); err != nil {
+		t.Fatal(err)
+	}
+	other := sha256.Sum256([]byte("other request"))
+	_, err := c.do(context.Background(), "k", other, func() (string, error) { return "o2", nil })
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("do with another request = %v, want InvalidArgument", err)
+	}
+}
+
+func TestIdempotencyCacheWaitIsCancelled(t *testing.T) {
+	c := newIdempotencyCache(time.Hour)
+	creating, proceed := make(chan struct{}), make(chan struct{})
+	go c.do(context.Background(), "k", testRequest, func() (string, error) {
+		close(creating)
+		<-proceed
+		return "o1", nil
+	})
+	defer close(proceed)
+	<-creating
+
+	// A caller racing on the key gives up waiting with its call.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.do(ctx, "k", testRequest, func() (string, error) { return "o2", nil }); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("do while the key is in use = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestIdempotencyKeysAreScopedByCaller(t *testing.T) {
+	key := func(subject string) string {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(idempotencyKeyHeader, "k"))
+		if subject != "" {
+			ctx = auth.NewContext(ctx, &auth.Principal{Subject: subject})
+		}
+		return idempotencyKey(ctx)
+	}
+	alice, bob, anonymous := key("alice"), key("bob"), key("")
+	if alice == bob || alice == anonymous || bob == anonymous {
+		t.Errorf("keys of alice %q, bob %q and an anonymous caller %q are not distinct", alice, bob, anonymous)
+	}
+	if key("alice") != alice {
+		t.Errorf("key of alice changed from %q to %q", alice, key("alice"))
+	}
+	if k := idempotencyKey(context.Background()); k != "" {
+		t.Errorf("key of a request without one = %q", k)
+	}
+}
+
+func TestAddOrderIdempotencyKey(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	ctx := metadata.AppendToOutgoingContext(context.Background(), idempotencyKeyHeader, "k1")
+	first, err := client.AddOrder(ctx, &pb.Order{Destination: sanJose, LineItems: []*pb.LineItem{{ProductId: "p1", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("addOrder: %v", err)
+	}
+
+	// A retry gets the order created by the first attempt, while another
+	// order sent with the same key is refused.
+	retry, err := client.AddOrder(ctx, &pb.Order{Destination: sanJose, LineItems: []*pb.LineItem{{ProductId: "p1", Quantity: 1}}})
+	if err != nil || retry.Id != first.Id {
+		t.Errorf("retried addOrder = %v, %v, want %v", retry, err, first.Id)
+	}
+	_, err = client.AddOrder(ctx, &pb.Order{Destination: sanJose, LineItems: []*pb.LineItem{{ProductId: "p1", Quantity: 2}}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("addOrder of another order with the key = %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	pb "ecommerce/order/proto"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// idSet is a set of order ids.
+type idSet map[string]struct{}
+
+// postings maps a token to the ids of the orders containing it.
+type postings map[string]idSet
+
+func (p postings) add(token, id string) {
+	ids, ok := p[token]
+	if !ok {
+		ids = make(idSet)
+		p[token] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (p postings) remove(token, id string) {
+	if ids, ok := p[token]; ok {
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(p, token)
+		}
+	}
+}
+
+// indexedOrder remembers which tokens an order was indexed under,
+// so that it can be unindexed when it changes.
+type indexedOrder struct {
+	items        []string
+	destinations []string
+}
+
+// orderIndex is an inverted index over the lower-cased tokens of order item
+// names and destinations. It only narrows down the orders a search has to
+// look at: every candidate it returns is still checked against the full
+// search predicate, so it may over-approximate but never miss a match.
+type orderIndex struct {
+	mu           sync.RWMutex
+	items        postings
+	destinations postings
+	docs         map[string]indexedOrder
+}
+
+func newOrderIndex(orders OrderStore) *orderIndex {
+	x := &orderIndex{
+		items:        make(postings),
+		destinations: make(postings),
+		docs:         make(map[string]indexedOrder),
+	}
+	orders.Scan(func(ord *pb.Order) bool {
+		x.indexLocked(ord)
+		return true
+	})
+	return x
+}
+
+// tokenize splits s into lower-cased runs of letters and digits.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// refresh re-indexes the order with the given id from its current value in
+// orders, dropping it from the index if it no longer exists. Reading the
+// store under the index lock means the last refresh always wins with the
+// latest write, whatever order concurrent writers call refresh in.
+func (x *orderIndex) refresh(orders OrderStore, id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.unindexLocked(id)
+	if ord, exists := orders.Get(id); exists {
+		x.indexLocked(ord)
+	}
+}
+
+func (x *orderIndex) indexLocked(ord *pb.Order) {
+	doc := indexedOrder{destinations: tokenize(ord.Destination)}
+	for _, itemName := range ord.Items {
+		doc.items = append(doc.items, tokenize(itemName)...)
+	}
+	for _, token := range doc.items {
+		x.items.add(token, ord.Id)
+	}
+	for _, token := range doc.destinations {
+		x.destinations.add(token, ord.Id)
+	}
+	x.docs[ord.Id] = doc
+}
+
+func (x *orderIndex) unindexLocked(id string) {
+	doc, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	for _, token := range doc.items {
+		x.items.remove(token, id)
+	}
+	for _, token := range doc.destinations {
+		x.destinations.remove(token, id)
+	}
+	delete(x.docs, id)
+}
+
+// candidates returns the ids of the orders that may match req.
+// ok is false when the index cannot narrow the search down,
+// e.g. for a pure price or status filter, and a full scan is needed.
+func (x *orderIndex) candidates(req *pb.SearchRequest) (ids idSet, ok bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	var sets []idSet
+	if req.S != "" {
+		if ids, ok := x.lookup(x.items, &pb.StringMatch{Value: req.S}); ok {
+			sets = append(sets, ids)
+		}
+	}
+	if req.Filter != nil {
+		if ids, ok := x.filterCandidates(req.Filter); ok {
+			sets = append(sets, ids)
+		}
+	}
+	if len(sets) == 0 {
+		return nil, false
+	}
+	return intersect(sets), true
+}
+
+func (x *orderIndex) filterCandidates(f *pb.Filter) (idSet, bool) {
+	switch kind := f.Kind.(type) {
+	case *pb.Filter_AllOf:
+		var sets []idSet
+		for _, child := range kind.AllOf.GetFilters() {
+			if ids, ok := x.filterCandidates(child); ok {
+				sets = append(sets, ids)
+			}
+		}
+		if len(sets) == 0 {
+			return nil, false
+		}
+		return intersect(sets), true
+
+	case *pb.Filter_AnyOf:
+		union := make(idSet)
+		for _, child := range kind.AnyOf.GetFilters() {
+			ids, ok := x.filterCandidates(child)
+			if !ok {
+				return nil, false
+			}
+			for id := range ids {
+				union[id] = struct{}{}
+			}
+		}
+		return union, true
+
+	case *pb.Filter_Destination:
+		return x.lookup(x.destinations, kind.Destination)
+	case *pb.Filter_Item:
+		return x.lookup(x.items, kind.Item)
+	}
+	return nil, false
+}
+
+// lookup returns the orders having, for every token of the match value,
+// an indexed token compatible with it under the match mode.
+//
+// For an EXACT match each query token must be an indexed token. For a PREFIX
+// match the last query token may end inside an indexed token. For CONTAINS
+// the first token may also start inside one, and a lone token anywhere.
+func (x *orderIndex) lookup(p postings, m *pb.StringMatch) (idSet, bool) {
+	tokens := tokenize(m.Value)
+	if len(tokens) == 0 {
+		return nil, false
+	}
+
+	// Whether the value starts or ends in the middle of a word.
+	first, last := 0, len(tokens)-1
+	sets := make([]idSet, 0, len(tokens))
+	for i, token := range tokens {
+		var match func(indexed string) bool
+		switch {
+		case m.Mode == pb.MatchMode_MATCH_MODE_EXACT:
+		case m.Mode == pb.MatchMode_MATCH_MODE_PREFIX && i == last:
+			match = func(indexed string) bool { return strings.HasPrefix(indexed, token) }
+		case m.Mode == pb.MatchMode_MATCH_MODE_CONTAINS && i == first && i == last:
+			match = func(indexed string) bool { return strings.Contains(indexed, token) }
+		case m.Mode == pb.MatchMode_MATCH_MODE_CONTAINS && i == first:
+			match = func(indexed string) bool { return strings.HasSuffix(indexed, token) }
+		case m.Mode == pb.MatchMode_MATCH_MODE_CONTAINS && i == last:
+			match = func(indexed string) bool { return strings.HasPrefix(indexed, token) }
+		}
+
+		if match == nil {
+			sets = append(sets, p[token])
+			continue
+		}
+		ids := make(idSet)
+		for indexed, posting := range p {
+			if match(indexed) {
+				for id := range posting {
+					ids[id] = struct{}{}
+				}
+			}
+		}
+		sets = append(sets, ids)
+	}
+	return intersect(sets), true
+}
+
+// intersect returns the ids present in every set.
+func intersect(sets []idSet) idSet {
+	smallest := 0
+	for i, set := range sets {
+		if len(set) < len(sets[smallest]) {
+			smallest = i
+		}
+	}
+
+	out := make(idSet, len(sets[smallest]))
+next:
+	for id := range sets[smallest] {
+		for i, set := range sets {
+			if i == smallest {
+				continue
+			}
+			if _, ok := set[id]; !ok {
+				continue next
+			}
+		}
+		out[id] = struct{}{}
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	inventorypb "ecommerce/inventory/proto"
+	inventoryserver "ecommerce/inventory/server"
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	productpb "ecommerce/product/proto"
+	productserver "ecommerce/product/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"testing"
+)
+
+// services are the clients of an order service backed by real product and
+// inventory services, all served in process.
+type services struct {
+	orders    pb.OrderManagementClient
+	products  productpb.ProductInfoClient
+	inventory inventorypb.InventoryClient
+}
+
+// startServices serves the product and inventory services, with their
+// requests validated as in their main, and an order service using them.
+func startServices(t *testing.T) services {
+	t.Helper()
+	pv := productserver.NewValidator()
+	productConn := serve(t, func(s *grpc.Server) { productpb.RegisterProductInfoServer(s, productserver.New()) },
+		grpc.ChainUnaryInterceptor(pv.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(pv.StreamServerInterceptor()))
+	iv := inventoryserver.NewValidator()
+	inventoryConn := serve(t, func(s *grpc.Server) { inventorypb.RegisterInventoryServer(s, inventoryserver.New()) },
+		grpc.ChainUnaryInterceptor(iv.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(iv.StreamServerInterceptor()))
+
+	products := productpb.NewProductInfoClient(productConn)
+	inventory := inventorypb.NewInventoryClient(inventoryConn)
+	orders := startServer(t, newMemOrderStore(), &productCatalog{client: products}, &inventoryStock{client: inventory})
+	return services{orders: orders, products: products, inventory: inventory}
+}
+
+// checkStock checks the on hand and reserved units of product.
+func (s services) checkStock(t *testing.T, product string, onHand, reserved int64) {
+	t.Helper()
+	level, err := s.inventory.GetStock(context.Background(), &inventorypb.ProductId{Id: product})
+	if err != nil {
+		t.Fatalf("getStock %v: %v", product, err)
+	}
+	if level.OnHand != onHand || level.Reserved != reserved {
+		t.Errorf("product %v has %v on hand, %v reserved, want %v and %v", product, level.OnHand, level.Reserved, onHand, reserved)
+	}
+}
+
+func checkCode(t *testing.T, what string, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Errorf("%v: got %v (%v), want %v", what, got, err, want)
+	}
+}
+
+func TestOrderLifecycleAcrossServices(t *testing.T) {
+	s := startServices(t)
+	ctx := context.Background()
+
+	id, err := s.products.AddProduct(ctx, &productpb.Product{Name: "Google Pixel 3A", Price: money.New("USD", 400, 0)})
+	if err != nil {
+		t.Fatalf("addProduct: %v", err)
+	}
+	product := id.Value
+	if _, err := s.inventory.SetStock(ctx, &inventorypb.SetStockRequest{ProductId: product, OnHand: 5}); err != nil {
+		t.Fatalf("setStock: %v", err)
+	}
+	order := func(quantity int32) *pb.Order {
+		return &pb.Order{Destination: "San Jose, CA", LineItems: []*pb.LineItem{{ProductId: product, Quantity: quantity}}}
+	}
+
+	// Placing an order prices it from the catalog and reserves its stock.
+	placed, err := s.orders.AddOrder(ctx, order(2))
+	if err != nil {
+		t.Fatalf("addOrder: %v", err)
+	}
+	ord, err := s.orders.GetOrder(ctx, placed)
+	if err != nil {
+		t.Fatalf("getOrder: %v", err)
+	}
+	if money.Format(ord.Price) != money.Format(money.New("USD", 800, 0)) || len(ord.Items) != 1 || ord.Items[0] != "Google Pixel 3A" {
+		t.Errorf("order priced %v for %v, want 800 USD for the Google Pixel 3A", money.Format(ord.Price), ord.Items)
+	}
+	s.checkStock(t, product, 5, 2)
+
+	// Orders the catalog or the stock cannot serve are refused, and hold nothing.
+	_, err = s.orders.AddOrder(ctx, order(4))
+	checkCode(t, "addOrder of more units than available", err, codes.FailedPrecondition)
+	_, err = s.orders.AddOrder(ctx, &pb.Order{Destination: "San Jose, CA", LineItems: []*pb.LineItem{{ProductId: "missing", Quantity: 1}}})
+	checkCode(t, "addOrder of an unknown product", err, codes.InvalidArgument)
+	s.checkStock(t, product, 5, 2)
+
+	// Orders created by updateOrders reserve their stock too.
+	stream, err := s.orders.UpdateOrders(ctx)
+	if err != nil {
+		t.Fatalf("updateOrders: %v", err)
+	}
+	created := order(1)
+	created.Id = "created"
+	if err := stream.Send(created); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("updateOrders: %v", err)
+	}
+	if outcome := resp.Results[0].Outcome; outcome != pb.UpdateOutcome_UPDATE_OUTCOME_CREATED {
+		t.Fatalf("updateOrders: got %v (%v), want CREATED", outcome, resp.Results[0].Status.GetMessage())
+	}
+	s.checkStock(t, product, 5, 3)
+
+	// Cancelling an order releases its stock.
+	if _, err := s.orders.CancelOrder(ctx, &pb.OrderId{Id: "created"}); err != nil {
+		t.Fatalf("cancelOrder: %v", err)
+	}
+	s.checkStock(t, product, 5, 2)
+
+	// Processing an order takes its stock out of the warehouse.
+	process, err := s.orders.ProcessOrders(ctx)
+	if err != nil {
+		t.Fatalf("processOrders: %v", err)
+	}
+	if err := process.Send(placed); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if err := process.CloseSend(); err != nil {
+		t.Fatalf("close send: %v", err)
+	}
+	for {
+		res, err := process.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("processOrders: %v", err)
+		}
+		if res.GetShipment() == nil {
+			t.Errorf("processOrders: got %v, want a shipment", describe(res))
+		}
+	}
+	s.checkStock(t, product, 3, 0)
+}
@@ -2,15 +2,26 @@ package main
 
 import (
 	"context"
+	"ecommerce/auth"
+	inventorypb "ecommerce/inventory/proto"
+	"ecommerce/middleware"
+	"ecommerce/money"
 	pb "ecommerce/order/proto"
-	"fmt"
+	productpb "ecommerce/product/proto"
+	"ecommerce/rpcerr"
+	"ecommerce/tlsconfig"
+	"ecommerce/validation"
+	"errors"
+	"flag"
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"io"
 	"log"
 	"net"
-	"strings"
+	"path/filepath"
+	"time"
 )
 
 const (
@@ -19,12 +30,52 @@ const (
 	orderBatchSize = 3
 )
 
-var orderMap = make(map[string]*pb.Order)
+var (
+	dataDir        = flag.String("data-dir", "", "directory for the durable order store (write-ahead log + snapshots)")
+	storeFile      = flag.String("store-file", "", "persist orders to this file instead of keeping them in memory")
+	productAddr    = flag.String("product-addr", "localhost:50081", "address of the product service")
+	inventoryAddr  = flag.String("inventory-addr", "localhost:50083", "address of the inventory service")
+	watchHistory   = flag.Int("watch-history", 1024, "order changes kept for watchOrders streams resuming after a reconnect")
+	serverTLS      = tlsconfig.ServerFlags("")
+	serverAuth     = auth.ServerFlags()
+	metricsAddr    = flag.String("metrics-addr", "", "serve call metrics at /debug/vars on this address, e.g. :9082")
+	dependencyTLS  = tlsconfig.ClientFlags("dependency-")
+	dependencyAuth = auth.ClientFlags("dependency-")
+
+	// Protection of the calls to the product and inventory services, see dialDependency.
+	dependencyTimeout         = flag.Duration("dependency-timeout", 500*time.Millisecond, "deadline of each call to another service")
+	dependencyAttempts        = flag.Int("dependency-attempts", 3, "attempts per call to another service, including retries of transient failures")
+	dependencyBreakerFailures = flag.Int("dependency-breaker-failures", 5, "consecutive failures of another service that open its circuit breaker")
+	dependencyBreakerCooldown = flag.Duration("dependency-breaker-cooldown", 10*time.Second, "how long an open circuit breaker fails calls before probing again")
+)
 
 type Server struct {
 	pb.OrderManagementServer
 	//pb.UnimplementedOrderManagementServer
-	orderMap map[string]*pb.Order
+	orders      OrderStore
+	feed        *orderFeed
+	shipments   ShipmentStore
+	trackers    *shipmentTrackers
+	catalog     Catalog
+	stock       Stock
+	transitions *keyedMutex
+	index       *orderIndex
+	idempotency *idempotencyCache
+}
+
+// newServer returns a server for orders, whose changes are published to feed.
+func newServer(orders OrderStore, feed *orderFeed, shipments ShipmentStore, catalog Catalog, stock Stock) *Server {
+	return &Server{
+		orders:      feed.watch(orders),
+		feed:        feed,
+		shipments:   shipments,
+		trackers:    newShipmentTrackers(),
+		catalog:     catalog,
+		stock:       stock,
+		transitions: newKeyedMutex(),
+		index:       newOrderIndex(orders),
+		idempotency: newIdempotencyCache(idempotencyTTL),
+	}
 }
 
 func (s *Server) mustEmbedUnimplementedOrderManagementServer() {
@@ -32,7 +83,43 @@ func (s *Server) mustEmbedUnimplementedOrderManagementServer() {
 }
 
 func main() {
-	initSampleData()
+	flag.Parse()
+
+	var orders OrderStore = newMemOrderStore()
+	var shipments ShipmentStore = newMemShipmentStore()
+	switch {
+	case *dataDir != "":
+		durableStore, err := openDurableOrderStore(filepath.Join(*dataDir, "orders"))
+		if err != nil {
+			log.Fatalf("%v failed to open order store in %v: %v\n\n", tag, *dataDir, err)
+		}
+		defer durableStore.Close()
+		log.Printf("%v Recovered %v orders from %v\n", tag, len(durableStore.List()), *dataDir)
+		orders = durableStore
+
+		durableShipments, err := openDurableShipmentStore(filepath.Join(*dataDir, "shipments"))
+		if err != nil {
+			log.Fatalf("%v failed to open shipment store in %v: %v\n\n", tag, *dataDir, err)
+		}
+		defer durableShipments.Close()
+		log.Printf("%v Recovered %v shipments from %v\n", tag, len(durableShipments.List()), *dataDir)
+		shipments = durableShipments
+	case *storeFile != "":
+		fileStore, err := openFileOrderStore(*storeFile)
+		if err != nil {
+			log.Fatalf("%v failed to open order store %v: %v\n\n", tag, *storeFile, err)
+		}
+		orders = fileStore
+	}
+	if n, err := unlinkLostShipments(orders, shipments); err != nil {
+		log.Fatalf("%v failed to unlink orders from lost shipments: %v\n\n", tag, err)
+	} else if n > 0 {
+		log.Printf("%v Unlinked %v orders from shipments that were not kept\n", tag, n)
+	}
+	if len(orders.List()) == 0 {
+		initSampleData(orders)
+	}
+
 	lis, err := net.Listen("tcp", port)
 
 	if err != nil {
@@ -40,8 +127,57 @@ func main() {
 	}
 	log.Printf("%v Listening on port %v\n\n", tag, port)
 
-	s := grpc.NewServer()
-	pb.RegisterOrderManagementServer(s, &Server{})
+	v := newOrderValidator()
+	creds, err := serverTLS.Credentials()
+	if err != nil {
+		log.Fatalf("%v failed to load TLS credentials: %v\n\n", tag, err)
+	}
+	authenticator, err := serverAuth.Authenticator()
+	if err != nil {
+		log.Fatalf("%v failed to load authentication keys: %v\n\n", tag, err)
+	}
+	if authenticator == nil {
+		log.Printf("%v Authentication is disabled, every caller is admitted\n", tag)
+	}
+	observer := middleware.NewObserver(tag)
+	if *metricsAddr != "" {
+		go func() {
+			log.Fatalf("%v failed to serve metrics: %v\n\n", tag, middleware.ServeMetrics(*metricsAddr, observer))
+		}()
+		log.Printf("%v Serving metrics on %v/debug/vars\n", tag, *metricsAddr)
+	}
+	// Every call is observed, then recovered from panics. Callers are
+	// authenticated and authorized before their requests are validated.
+	s := grpc.NewServer(
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(
+			observer.UnaryServerInterceptor(),
+			middleware.UnaryServerRecovery(tag),
+			authenticator.UnaryServerInterceptor(orderPolicy),
+			v.UnaryServerInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			observer.StreamServerInterceptor(),
+			middleware.StreamServerRecovery(tag),
+			authenticator.StreamServerInterceptor(orderPolicy),
+			v.StreamServerInterceptor(),
+		),
+	)
+	productConn, err := dialDependency(productService, *productAddr)
+	if err != nil {
+		log.Fatalf("%v failed to dial product service %v: %v\n\n", tag, *productAddr, err)
+	}
+	defer productConn.Close()
+	catalog := &productCatalog{client: productpb.NewProductInfoClient(productConn)}
+
+	inventoryConn, err := dialDependency(inventoryService, *inventoryAddr)
+	if err != nil {
+		log.Fatalf("%v failed to dial inventory service %v: %v\n\n", tag, *inventoryAddr, err)
+	}
+	defer inventoryConn.Close()
+	stock := &inventoryStock{client: inventorypb.NewInventoryClient(inventoryConn)}
+
+	pb.RegisterOrderManagementServer(s, newServer(orders, newOrderFeed(*watchHistory), shipments, catalog, stock))
 	// Register reflection service on gRPC server.
 	// reflection.Register(s)
 	if err := s.Serve(lis); err != nil {
@@ -51,135 +187,222 @@ func main() {
 
 // GetOrder Simple RPC
 func (s *Server) GetOrder(_ context.Context, orderId *pb.OrderId) (*pb.Order, error) {
-	tag0 := tag + " [R]"
-	log.Printf("%v [Invoked]\n", tag0)
-	defer log.Printf("%v [End]\n\n", tag0)
-
-	ord, exists := orderMap[orderId.Id]
+	ord, exists := s.orders.Get(orderId.Id)
 	if exists {
 		return ord, status.New(codes.OK, "").Err()
 	}
 
-	return nil, status.Errorf(codes.NotFound, "Order does not exist. : ", orderId)
+	return nil, rpcerr.NotFound(orderResource, orderId.Id)
 }
 
 // AddOrder Simple RPC
-func (s *Server) AddOrder(_ context.Context, req *pb.Order) (*pb.OrderId, error) {
+// The server generates the id when none is given. Creating an id that
+// already exists fails with AlreadyExists, unless the request carries the
+// idempotency key of the create that made it. An idempotency key reused by
+// the same caller for a different request fails with InvalidArgument.
+func (s *Server) AddOrder(ctx context.Context, req *pb.Order) (*pb.OrderId, error) {
 	tag0 := tag + " [C]"
-	log.Printf("%v [Invoked]\n", tag0)
-	defer log.Printf("%v [End]\n\n", tag0)
 
-	orderMap[req.Id] = req
-	return &pb.OrderId{Id: req.Id}, nil
-}
+	// Hashed as sent, before the fields the server sets.
+	request, err := requestHash(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := startOrder(req); err != nil {
+		return nil, err
+	}
 
-// SearchOrders Server-side Streaming RPC
-func (s *Server) SearchOrders(req *pb.SearchRequest, stream pb.OrderManagement_SearchOrdersServer) error {
-	tag0 := tag + " [SS]"
-	log.Printf("%v [Invoked]\n", tag0)
-	defer log.Printf("%v [End]\n\n", tag0)
-
-	for key, ord := range orderMap {
-		log.Printf("%v [ORDER] %v\n", tag0, ord)
-		for _, itemName := range ord.Items {
-			log.Printf("%v [ITEM]\t%v\n", tag0, itemName)
-			if strings.Contains(itemName, req.S) {
-				// Send the matching orders in a stream
-				err := stream.Send(ord)
-				if err != nil {
-					return fmt.Errorf("error sending message to stream : %v", err)
-				}
-				log.Printf("%v [Found] %v\n", tag0, key)
-				break
+	create := func() (string, error) {
+		if req.Id == "" {
+			id, err := uuid.NewUUID()
+			if err != nil {
+				return "", rpcerr.Internal("Generating order ID: %v", err)
+			}
+			req.Id = id.String()
+		}
+		resolveAddress(req)
+		if err := priceOrder(ctx, s.catalog, req); err != nil {
+			return "", err
+		}
+		req.ReservationId = ""
+		req.ShipmentId = ""
+		if len(req.LineItems) > 0 {
+			id, err := uuid.NewUUID()
+			if err != nil {
+				return "", rpcerr.Internal("Generating reservation ID: %v", err)
+			}
+			if err := s.stock.Reserve(ctx, id.String(), req.LineItems); err != nil {
+				return "", err
+			}
+			req.ReservationId = id.String()
+		}
+		_, err := s.orders.Update(req.Id, func(current *pb.Order) (*pb.Order, error) {
+			if current != nil {
+				return nil, rpcerr.AlreadyExists(orderResource, req.Id)
 			}
+			req.Version = 1
+			return req, nil
+		})
+		if err != nil {
+			s.releaseStock(tag0, req)
+			return "", storeError(err, req.Id)
 		}
+		s.index.refresh(s.orders, req.Id)
+		return req.Id, nil
 	}
 
-	return nil
+	var id string
+	if key := idempotencyKey(ctx); key != "" {
+		id, err = s.idempotency.do(ctx, key, request, create)
+	} else {
+		id, err = create()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pb.OrderId{Id: id}, nil
 }
 
-// UpdateOrders Client-side Streaming RPC
-func (s *Server) UpdateOrders(stream pb.OrderManagement_UpdateOrdersServer) error {
-	tag0 := tag + " [CS-UO]"
-	log.Printf("%v [Invoked]\n", tag0)
-	defer log.Printf("%v [End]\n\n", tag0)
+// CancelOrder Simple RPC
+func (s *Server) CancelOrder(ctx context.Context, orderId *pb.OrderId) (*pb.Order, error) {
+	tag0 := tag + " [X]"
 
-	var orders []string
-	for {
-		order, err := stream.Recv()
-		if err == io.EOF {
-			// Finished reading the order stream.
-			return stream.SendAndClose(&pb.UpdateOrdersRequest{Id: orders})
-		}
+	return s.transition(ctx, tag0, orderId.Id, pb.OrderStatus_ORDER_STATUS_CANCELLED)
+}
 
-		if err != nil {
-			return err
-		}
-		// Update order
-		orderMap[order.Id] = order
+// TransitionOrder Simple RPC
+func (s *Server) TransitionOrder(ctx context.Context, req *pb.TransitionRequest) (*pb.Order, error) {
+	tag0 := tag + " [T]"
 
-		log.Printf("%v Order ID : %s - Updated\n", tag0, order.Id)
-		orders = append(orders, order.Id)
+	ord, err := s.transition(ctx, tag0, req.Id, req.Status)
+	if err != nil {
+		return nil, err
 	}
+	log.Printf("%v Order ID : %s - %v\n", tag0, ord.Id, ord.Status)
+	return ord, nil
 }
 
 // ProcessOrders Bi-directional Streaming RPC
 func (s *Server) ProcessOrders(stream pb.OrderManagement_ProcessOrdersServer) error {
 	tag0 := tag + " [BI]"
-	log.Printf("%v [Invoked]\n", tag0)
-	defer log.Printf("%v [End]\n\n", tag0)
 
-	batchMarker := 1
-	var shipmentMap = make(map[string]*pb.CombinedShipment)
+	policy, err := batchPolicyFromContext(stream.Context())
+	if err != nil {
+		return err
+	}
+	log.Printf("%v [Policy] %v\n", tag0, policy)
+
+	// Receive on a separate goroutine so that time based policies
+	// can flush while the client is idle. Ids rejected by the validation
+	// interceptor are passed on with their error.
+	type recvResult struct {
+		orderId *pb.OrderId
+		invalid error
+	}
+	orderIds := make(chan recvResult)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			orderId, err := stream.Recv()
+			var invalid *validation.Error
+			if errors.As(err, &invalid) {
+				orderId, err = invalid.Message.(*pb.OrderId), nil
+			}
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			r := recvResult{orderId: orderId}
+			if invalid != nil {
+				r.invalid = invalid
+			}
+			select {
+			case orderIds <- r:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	tick, stop := policy.ticker()
+	defer stop()
+
+	received := 0
+	batch := newShipmentBatch()
 	for {
-		orderId, err := stream.Recv()
-		if err == io.EOF {
-			// Client has sent all the messages
-			// Send remaining shipments
-			for _, comb := range shipmentMap {
-				log.Printf("%v [CMB Shipping] %20v -> %v\n", tag0, comb.Id, len(comb.OrdersList))
-				if err := stream.Send(comb); err != nil {
+		select {
+		case err := <-recvErr:
+			if err == io.EOF {
+				// Client has sent all the messages
+				// Send remaining shipments
+				if err := s.sendShipments(stream, tag0, batch.drain()); err != nil {
 					return err
 				}
+				log.Printf("%v [EOF]\n", tag0)
+				return nil
 			}
-			log.Printf("%v [EOF]\n", tag0)
-			return nil
-		}
-
-		if err != nil {
 			log.Println(err)
 			return err
-		}
 
-		log.Printf("%v [Recv] %v\n", tag0, orderId)
-		destination := orderMap[orderId.Id].Destination
-		shipment, found := shipmentMap[destination]
-
-		if !found {
-			shipment = &pb.CombinedShipment{Id: fmt.Sprint(orderMap[orderId.Id].Destination)}
-			shipmentMap[destination] = shipment
-		}
-		shipment.OrdersList = append(shipment.OrdersList, orderMap[orderId.Id])
+		case <-tick:
+			if err := s.sendShipments(stream, tag0, batch.drain()); err != nil {
+				return err
+			}
 
-		if batchMarker == orderBatchSize {
-			for _, comb := range shipmentMap {
-				log.Printf("%v [CMB Shipping] %20v -> %v\n", tag0, comb.Id, len(comb.OrdersList))
-				if err := stream.Send(comb); err != nil {
+		case r := <-orderIds:
+			orderId := r.orderId
+			log.Printf("%v [Recv] %v\n", tag0, orderId)
+			if r.invalid != nil {
+				if err := sendRejection(stream, tag0, orderId.Id, r.invalid); err != nil {
+					return err
+				}
+				continue
+			}
+			ord, exists := s.orders.Get(orderId.Id)
+			if !exists {
+				reason := rpcerr.NotFound(orderResource, orderId.Id)
+				if err := sendRejection(stream, tag0, orderId.Id, reason); err != nil {
+					return err
+				}
+				continue
+			}
+			if ord.ShipmentId != "" {
+				// Checked again when the shipment is recorded.
+				if err := sendRejection(stream, tag0, orderId.Id, alreadyInShipment(ord)); err != nil {
+					return err
+				}
+				continue
+			}
+			// The order goes into the batch first, so that an order that
+			// cannot be shipped is not moved to PROCESSING for nothing.
+			key, err := batch.add(ord)
+			if err != nil {
+				if err := sendRejection(stream, tag0, orderId.Id, err); err != nil {
 					return err
 				}
+				continue
+			}
+			if ord.Status != pb.OrderStatus_ORDER_STATUS_PROCESSING {
+				if _, err := s.transition(stream.Context(), tag0, orderId.Id, pb.OrderStatus_ORDER_STATUS_PROCESSING); err != nil {
+					batch.remove(key, ord)
+					if err := sendRejection(stream, tag0, orderId.Id, err); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			received++
+			if err := s.sendShipments(stream, tag0, policy.afterAdd(batch, key, received)); err != nil {
+				return err
 			}
-			batchMarker = 0
-			shipmentMap = make(map[string]*pb.CombinedShipment)
-		} else {
-			batchMarker++
 		}
 	}
 }
 
-func initSampleData() {
-	orderMap["102"] = &pb.Order{Id: "102", Items: []string{"Google Pixel 3A", "Mac Book Pro"}, Destination: "Mountain View, CA", Price: 1800.00}
-	orderMap["103"] = &pb.Order{Id: "103", Items: []string{"Apple Watch S4"}, Destination: "San Jose, CA", Price: 400.00}
-	orderMap["104"] = &pb.Order{Id: "104", Items: []string{"Google Home Mini", "Google Nest Hub"}, Destination: "Mountain View, CA", Price: 400.00}
-	orderMap["105"] = &pb.Order{Id: "105", Items: []string{"Amazon Echo"}, Destination: "San Jose, CA", Price: 30.00}
-	orderMap["106"] = &pb.Order{Id: "106", Items: []string{"Amazon Echo", "Apple iPhone XS"}, Destination: "Mountain View, CA", Price: 300.00}
+func initSampleData(orders OrderStore) {
+	orders.Put(&pb.Order{Id: "102", Items: []string{"Google Pixel 3A", "Mac Book Pro"}, Destination: "Mountain View, CA", Price: money.New("USD", 1800, 0), Status: pb.OrderStatus_ORDER_STATUS_PENDING, Version: 1})
+	orders.Put(&pb.Order{Id: "103", Items: []string{"Apple Watch S4"}, Destination: "San Jose, CA", Price: money.New("USD", 400, 0), Status: pb.OrderStatus_ORDER_STATUS_PENDING, Version: 1})
+	orders.Put(&pb.Order{Id: "104", Items: []string{"Google Home Mini", "Google Nest Hub"}, Destination: "Mountain View, CA", Price: money.New("USD", 400, 0), Status: pb.OrderStatus_ORDER_STATUS_PENDING, Version: 1})
+	orders.Put(&pb.Order{Id: "105", Items: []string{"Amazon Echo"}, Destination: "San Jose, CA", Price: money.New("USD", 30, 0), Status: pb.OrderStatus_ORDER_STATUS_PENDING, Version: 1})
+	orders.Put(&pb.Order{Id: "106", Items: []string{"Amazon Echo", "Apple iPhone XS"}, Destination: "Mountain View, CA", Price: money.New("USD", 300, 0), Status: pb.OrderStatus_ORDER_STATUS_PENDING, Version: 1})
 }
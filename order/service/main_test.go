@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	productpb "ecommerce/product/proto"
+	"ecommerce/rpcerr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// The handlers log every step, which would drown the test output.
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// fakeCatalog is a Catalog of fixed products.
+type fakeCatalog map[string]*productpb.Product
+
+func (c fakeCatalog) Product(_ context.Context, id string) (*productpb.Product, error) {
+	if p, ok := c[id]; ok {
+		return p, nil
+	}
+	return nil, rpcerr.NotFound("product.Product", id)
+}
+
+// testCatalog holds the products the tests order.
+var testCatalog = fakeCatalog{
+	"p1": {Id: "p1", Name: "Google Pixel 3A", Price: money.New("USD", 400, 0)},
+	"p2": {Id: "p2", Name: "Apple Watch S4", Price: money.New("USD", 300, 0)},
+}
+
+// fakeStock is a Stock of unlimited units, remembering what became of
+// every reservation.
+type fakeStock struct {
+	mu           sync.Mutex
+	reservations map[string]string // id -> "reserved", "committed" or "released"
+}
+
+func newFakeStock() *fakeStock {
+	return &fakeStock{reservations: make(map[string]string)}
+}
+
+func (s *fakeStock) Reserve(_ context.Context, id string, _ []*pb.LineItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.reservations[id]; !ok {
+		s.reservations[id] = "reserved"
+	}
+	return nil
+}
+
+func (s *fakeStock) Commit(_ context.Context, id string) error {
+	return s.settle(id, "committed")
+}
+
+func (s *fakeStock) Release(_ context.Context, id string) error {
+	return s.settle(id, "released")
+}
+
+func (s *fakeStock) settle(id, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch s.reservations[id] {
+	case "":
+		return rpcerr.NotFound("inventory.Reservation", id)
+	case "reserved", to:
+		s.reservations[id] = to
+		return nil
+	}
+	return rpcerr.FailedPrecondition(rpcerr.ReasonInvalidTransition, "inventory.Reservation", id, nil,
+		"Reservation %v is %v", id, s.reservations[id])
+}
+
+func (s *fakeStock) state(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reservations[id]
+}
+
+// serve starts a gRPC server over an in-memory listener, registering its
+// services with register, and returns a connection to it. Both are closed
+// when the test ends.
+func serve(t testing.TB, register func(s *grpc.Server), opts ...grpc.ServerOption) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(opts...)
+	register(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// startServer serves an order service keeping its orders in orders,
+// pricing them from catalog and reserving their stock in stock, with its
+// requests validated as in main, and returns a client of it.
+func startServer(t testing.TB, orders OrderStore, catalog Catalog, stock Stock) pb.OrderManagementClient {
+	t.Helper()
+	srv := newServer(orders, newOrderFeed(64), newMemShipmentStore(), catalog, stock)
+	v := newOrderValidator()
+	conn := serve(t, func(s *grpc.Server) { pb.RegisterOrderManagementServer(s, srv) },
+		grpc.ChainUnaryInterceptor(v.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(v.StreamServerInterceptor()))
+	return pb.NewOrderManagementClient(conn)
+}
+
+// newOrder returns an order of an Apple Watch shipped to destination.
+func newOrder(id, destination string) *pb.Order {
+	return &pb.Order{Id: id, Items: []string{"Apple Watch S4"}, Destination: destination, Price: money.New("USD", 400, 0)}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	"ecommerce/rpcerr"
+	"fmt"
+	moneypb "google.golang.org/genproto/googleapis/type/money"
+	"log"
+	"sort"
+	"strings"
+)
+
+// orderPredicate reports whether an order matches a search expression.
+type orderPredicate func(ord *pb.Order) bool
+
+func matchAll(*pb.Order) bool { return true }
+
+// compileSearch turns a SearchRequest into a single predicate.
+// The legacy free-text s and the structured filter are combined with AND.
+func compileSearch(req *pb.SearchRequest) (orderPredicate, error) {
+	pred := orderPredicate(matchAll)
+	if req.Filter != nil {
+		var err error
+		if pred, err = compileFilter(req.Filter); err != nil {
+			return nil, err
+		}
+	}
+	if req.S == "" {
+		return pred, nil
+	}
+
+	legacy := itemMatcher(func(itemName string) bool { return strings.Contains(itemName, req.S) })
+	return func(ord *pb.Order) bool { return legacy(ord) && pred(ord) }, nil
+}
+
+func compileFilter(f *pb.Filter) (orderPredicate, error) {
+	switch kind := f.Kind.(type) {
+	case *pb.Filter_AllOf:
+		preds, err := compileFilters(kind.AllOf.GetFilters())
+		if err != nil {
+			return nil, err
+		}
+		return func(ord *pb.Order) bool {
+			for _, p := range preds {
+				if !p(ord) {
+					return false
+				}
+			}
+			return true
+		}, nil
+
+	case *pb.Filter_AnyOf:
+		preds, err := compileFilters(kind.AnyOf.GetFilters())
+		if err != nil {
+			return nil, err
+		}
+		return func(ord *pb.Order) bool {
+			for _, p := range preds {
+				if p(ord) {
+					return true
+				}
+			}
+			return false
+		}, nil
+
+	case *pb.Filter_Destination:
+		match := compileStringMatch(kind.Destination)
+		return func(ord *pb.Order) bool { return match(ord.Destination) }, nil
+
+	case *pb.Filter_Item:
+		return itemMatcher(compileStringMatch(kind.Item)), nil
+
+	case *pb.Filter_Price:
+		r := kind.Price
+		for _, bound := range []*moneypb.Money{r.Min, r.Max} {
+			if bound == nil {
+				continue
+			}
+			if err := money.Validate(bound); err != nil {
+				return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, "filter.price", "price range: %v", err)
+			}
+		}
+		if r.Min != nil && r.Max != nil {
+			if r.Min.CurrencyCode != r.Max.CurrencyCode {
+				return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, "filter.price", "price range min is in %v but max in %v", r.Min.CurrencyCode, r.Max.CurrencyCode)
+			}
+			if money.Compare(r.Min, r.Max) > 0 {
+				return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, "filter.price", "price range min %v is greater than max %v", money.Format(r.Min), money.Format(r.Max))
+			}
+		}
+		currency := r.Min.GetCurrencyCode()
+		if r.Max != nil {
+			currency = r.Max.CurrencyCode
+		}
+		return func(ord *pb.Order) bool {
+			if currency != "" && ord.Price.GetCurrencyCode() != currency {
+				return false
+			}
+			return (r.Min == nil || money.Compare(ord.Price, r.Min) >= 0) && (r.Max == nil || money.Compare(ord.Price, r.Max) <= 0)
+		}, nil
+
+	case *pb.Filter_Status:
+		st := kind.Status
+		return func(ord *pb.Order) bool { return ord.Status == st }, nil
+
+	case nil:
+		return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, "filter", "empty filter")
+	default:
+		return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, "filter", "unsupported filter %T", kind)
+	}
+}
+
+func compileFilters(filters []*pb.Filter) ([]orderPredicate, error) {
+	preds := make([]orderPredicate, 0, len(filters))
+	for _, f := range filters {
+		p, err := compileFilter(f)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, p)
+	}
+	return preds, nil
+}
+
+func compileStringMatch(m *pb.StringMatch) func(string) bool {
+	want := m.Value
+	fold := func(s string) string { return s }
+	if m.IgnoreCase {
+		want = strings.ToLower(want)
+		fold = strings.ToLower
+	}
+
+	switch m.Mode {
+	case pb.MatchMode_MATCH_MODE_EXACT:
+		return func(s string) bool { return fold(s) == want }
+	case pb.MatchMode_MATCH_MODE_PREFIX:
+		return func(s string) bool { return strings.HasPrefix(fold(s), want) }
+	default:
+		return func(s string) bool { return strings.Contains(fold(s), want) }
+	}
+}
+
+func itemMatcher(match func(string) bool) orderPredicate {
+	return func(ord *pb.Order) bool {
+		for _, itemName := range ord.Items {
+			if match(itemName) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// orderLess returns the comparison used to sort search results.
+// Results are ordered by id unless another field is requested, and orders
+// that tie on that field are always ordered by ascending id, so the same
+// request over the same data streams the same sequence every time.
+func orderLess(field pb.SortField, descending bool) (func(a, b *pb.Order) bool, error) {
+	var cmp func(a, b *pb.Order) int
+	switch field {
+	case pb.SortField_SORT_FIELD_UNSPECIFIED, pb.SortField_SORT_FIELD_ID:
+		cmp = func(a, b *pb.Order) int { return strings.Compare(a.Id, b.Id) }
+	case pb.SortField_SORT_FIELD_PRICE:
+		cmp = func(a, b *pb.Order) int { return money.Compare(a.Price, b.Price) }
+	case pb.SortField_SORT_FIELD_DESTINATION:
+		cmp = func(a, b *pb.Order) int { return strings.Compare(a.Destination, b.Destination) }
+	default:
+		return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, "sort_by", "unsupported sort field %v", field)
+	}
+
+	return func(a, b *pb.Order) bool {
+		c := cmp(a, b)
+		if descending {
+			c = -c
+		}
+		if c == 0 {
+			return a.Id < b.Id
+		}
+		return c < 0
+	}, nil
+}
+
+// scanCandidates calls fn for the orders the index says may match req, or for
+// every order when the index cannot narrow the search, until fn returns false.
+func (s *Server) scanCandidates(req *pb.SearchRequest, fn func(ord *pb.Order) bool) {
+	ids, ok := s.index.candidates(req)
+	if !ok {
+		s.orders.Scan(fn)
+		return
+	}
+	for id := range ids {
+		if ord, exists := s.orders.Get(id); exists && !fn(ord) {
+			return
+		}
+	}
+}
+
+// SearchOrders Server-side Streaming RPC
+func (s *Server) SearchOrders(req *pb.SearchRequest, stream pb.OrderManagement_SearchOrdersServer) error {
+	tag0 := tag + " [SS]"
+
+	match, err := compileSearch(req)
+	if err != nil {
+		return err
+	}
+
+	less, err := orderLess(req.SortBy, req.Descending)
+	if err != nil {
+		return err
+	}
+
+	var matches []*pb.Order
+	s.scanCandidates(req, func(ord *pb.Order) bool {
+		if match(ord) {
+			matches = append(matches, ord)
+		}
+		return true
+	})
+	sort.Slice(matches, func(i, j int) bool { return less(matches[i], matches[j]) })
+	if req.Limit > 0 && len(matches) > int(req.Limit) {
+		matches = matches[:req.Limit]
+	}
+	for _, ord := range matches {
+		// Send the matching orders in a stream
+		if err := stream.Send(ord); err != nil {
+			return fmt.Errorf("error sending message to stream : %v", err)
+		}
+		log.Printf("%v [Found] %v\n", tag0, ord.Id)
+	}
+	return nil
+}
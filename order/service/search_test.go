@@ -0,0 +1,104 @@
+package main
+
+import (
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// searchOrders is the number of orders the search benchmarks run against.
+const searchOrders = 1000000
+
+var (
+	searchServerOnce sync.Once
+	searchServer     *Server
+)
+
+// newSearchServer returns a server holding n orders of 1000 products shipped
+// to 500 towns, each product and town shared by a thousandth and a five
+// hundredth of the orders.
+func newSearchServer(n int) *Server {
+	orders := newMemOrderStore()
+	for i := 0; i < n; i++ {
+		orders.Put(&pb.Order{
+			Id:          fmt.Sprintf("%07d", i),
+			Items:       []string{fmt.Sprintf("Model%04d", i%1000)},
+			Destination: fmt.Sprintf("Town%03d, CA", i%500),
+			Price:       money.New("USD", int64(i%1000), 0),
+			Status:      pb.OrderStatus_ORDER_STATUS_PENDING,
+		})
+	}
+	return newServer(orders, newOrderFeed(64), newMemShipmentStore(), testCatalog, newFakeStock())
+}
+
+// searchRequests are the searches compared with and without the index.
+var searchRequests = []struct {
+	name string
+	req  *pb.SearchRequest
+}{
+	{"S", &pb.SearchRequest{S: "Model0042"}},
+	{"ItemExact", &pb.SearchRequest{Filter: &pb.Filter{Kind: &pb.Filter_Item{Item: &pb.StringMatch{Value: "Model0042", Mode: pb.MatchMode_MATCH_MODE_EXACT}}}}},
+	{"ItemPrefix", &pb.SearchRequest{Filter: &pb.Filter{Kind: &pb.Filter_Item{Item: &pb.StringMatch{Value: "Model004", Mode: pb.MatchMode_MATCH_MODE_PREFIX}}}}},
+	{"DestinationContains", &pb.SearchRequest{Filter: &pb.Filter{Kind: &pb.Filter_Destination{Destination: &pb.StringMatch{Value: "own042", Mode: pb.MatchMode_MATCH_MODE_CONTAINS}}}}},
+	{"AllOf", &pb.SearchRequest{Filter: &pb.Filter{Kind: &pb.Filter_AllOf{AllOf: &pb.FilterList{Filters: []*pb.Filter{
+		{Kind: &pb.Filter_Item{Item: &pb.StringMatch{Value: "Model0042", Mode: pb.MatchMode_MATCH_MODE_EXACT}}},
+		{Kind: &pb.Filter_Destination{Destination: &pb.StringMatch{Value: "Town042, CA", Mode: pb.MatchMode_MATCH_MODE_EXACT}}},
+	}}}}}},
+}
+
+// search returns the ids of the orders of s matching req, looking them up
+// through the index unless scan is set.
+func search(tb testing.TB, s *Server, req *pb.SearchRequest, scan bool) []string {
+	match, err := compileSearch(req)
+	if err != nil {
+		tb.Fatalf("compileSearch: %v", err)
+	}
+	var ids []string
+	collect := func(ord *pb.Order) bool {
+		if match(ord) {
+			ids = append(ids, ord.Id)
+		}
+		return true
+	}
+	if scan {
+		s.orders.Scan(collect)
+	} else {
+		s.scanCandidates(req, collect)
+	}
+	return ids
+}
+
+func TestSearchIndexMatchesScan(t *testing.T) {
+	s := newSearchServer(10000)
+	for _, r := range searchRequests {
+		indexed, scanned := search(t, s, r.req, false), search(t, s, r.req, true)
+		sort.Strings(indexed)
+		sort.Strings(scanned)
+		if len(scanned) == 0 {
+			t.Errorf("%v: no order matches", r.name)
+		}
+		if fmt.Sprint(indexed) != fmt.Sprint(scanned) {
+			t.Errorf("%v: index found %d orders, scan %d", r.name, len(indexed), len(scanned))
+		}
+	}
+}
+
+func benchmarkSearch(b *testing.B, scan bool) {
+	searchServerOnce.Do(func() { searchServer = newSearchServer(searchOrders) })
+	for _, r := range searchRequests {
+		b.Run(r.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				search(b, searchServer, r.req, scan)
+			}
+		})
+	}
+}
+
+// BenchmarkSearchIndex searches the orders the index narrows a search to.
+func BenchmarkSearchIndex(b *testing.B) { benchmarkSearch(b, false) }
+
+// BenchmarkSearchScan searches every order, as done before the index.
+func BenchmarkSearchScan(b *testing.B) { benchmarkSearch(b, true) }
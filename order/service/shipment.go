@@ -0,0 +1,277 @@
+package main
+
+import (
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	"ecommerce/rpcerr"
+	"github.com/google/uuid"
+	moneypb "google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"sort"
+)
+
+// shipmentBatch collects the orders received by ProcessOrders between two
+// flushes, combined per normalized address and currency, so that every
+// shipment has a single total. Orders keep the order they were received in
+// and shipments are flushed sorted by address, then currency, so a given
+// input stream always produces the same output stream.
+type shipmentBatch struct {
+	shipments map[string]*pb.CombinedShipment
+	// orders holds the ids of the orders of every pending shipment.
+	orders map[string]bool
+}
+
+func newShipmentBatch() *shipmentBatch {
+	return &shipmentBatch{shipments: make(map[string]*pb.CombinedShipment), orders: make(map[string]bool)}
+}
+
+// add appends ord to the shipment for its address and currency, opening
+// a shipment with a new id if there is none, and returns the key of that
+// shipment. It fails, leaving the batch unchanged, if ord is pending already
+// or if the shipment total would overflow.
+func (b *shipmentBatch) add(ord *pb.Order) (string, error) {
+	if b.orders[ord.Id] {
+		return "", rpcerr.FailedPrecondition(rpcerr.ReasonAlreadyInShipment, orderResource, ord.Id, nil,
+			"Order %v was already received", ord.Id)
+	}
+	currency := ord.Price.GetCurrencyCode()
+	address := orderAddress(ord)
+	key := addressKey(address) + "\x00" + currency
+	shipment, found := b.shipments[key]
+	if !found {
+		id, err := uuid.NewUUID()
+		if err != nil {
+			return "", rpcerr.Internal("Generating shipment ID: %v", err)
+		}
+		shipment = &pb.CombinedShipment{
+			Id:          id.String(),
+			Destination: formatAddress(address),
+			Address:     address,
+			Total:       money.New(currency, 0, 0),
+		}
+	}
+	total, err := money.Add(shipment.Total, ord.Price)
+	if err != nil {
+		return "", rpcerr.Internal("Combining order %v: %v", ord.Id, err)
+	}
+	shipment.Total = total
+	shipment.OrdersList = append(shipment.OrdersList, ord)
+	b.shipments[key] = shipment
+	b.orders[ord.Id] = true
+	return key, nil
+}
+
+// remove takes ord, the order added last to the shipment under key, back
+// out of the batch.
+func (b *shipmentBatch) remove(key string, ord *pb.Order) {
+	shipment := b.shipments[key]
+	delete(b.orders, ord.Id)
+	shipment.OrdersList = shipment.OrdersList[:len(shipment.OrdersList)-1]
+	if len(shipment.OrdersList) == 0 {
+		delete(b.shipments, key)
+		return
+	}
+	prices := make([]*moneypb.Money, len(shipment.OrdersList))
+	for i, o := range shipment.OrdersList {
+		prices[i] = o.Price
+	}
+	// The remaining orders were added up before, their sum cannot overflow.
+	shipment.Total, _ = money.Sum(shipment.Total.CurrencyCode, prices...)
+}
+
+// take removes and returns the pending shipment under key.
+func (b *shipmentBatch) take(key string) *pb.CombinedShipment {
+	shipment := b.shipments[key]
+	delete(b.shipments, key)
+	for _, ord := range shipment.OrdersList {
+		delete(b.orders, ord.Id)
+	}
+	return shipment
+}
+
+// drain removes and returns every pending shipment, sorted by address and currency.
+func (b *shipmentBatch) drain() []*pb.CombinedShipment {
+	keys := make([]string, 0, len(b.shipments))
+	for key := range b.shipments {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	shipments := make([]*pb.CombinedShipment, 0, len(keys))
+	for _, key := range keys {
+		shipments = append(shipments, b.shipments[key])
+	}
+	b.shipments = make(map[string]*pb.CombinedShipment)
+	b.orders = make(map[string]bool)
+	return shipments
+}
+
+// sendShipments records shipments, then sends them to the client, preceded
+// by the rejections of the orders left out of them.
+func (s *Server) sendShipments(stream pb.OrderManagement_ProcessOrdersServer, tag0 string, shipments []*pb.CombinedShipment) error {
+	for _, pending := range shipments {
+		comb, rejected, err := s.recordShipment(pending)
+		if err != nil {
+			return err
+		}
+		for _, r := range rejected {
+			if err := sendRejection(stream, tag0, r.orderId, r.reason); err != nil {
+				return err
+			}
+		}
+		if comb == nil {
+			continue
+		}
+		log.Printf("%v [CMB Shipping] %v %20v -> %v (%v)\n", tag0, comb.Id, comb.Destination, len(comb.OrdersList), money.Format(comb.Total))
+		if err := stream.Send(&pb.ProcessResult{Result: &pb.ProcessResult_Shipment{Shipment: comb}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rejectedOrder is an order left out of a shipment, and why.
+type rejectedOrder struct {
+	orderId string
+	reason  error
+}
+
+// recordShipment links the orders of comb to it and stores it as PROCESSING.
+// Orders put in another shipment, or no longer PROCESSING, e.g. cancelled,
+// since ProcessOrders received them are left out and returned with the
+// reason; the shipment is nil if no order is left.
+//
+// Orders are written first, checked and linked in one store update, so an
+// order never ends up in two shipments. Should storing the shipment fail,
+// they are unlinked again.
+func (s *Server) recordShipment(comb *pb.CombinedShipment) (*pb.CombinedShipment, []rejectedOrder, error) {
+	ids := make([]string, len(comb.OrdersList))
+	for i, ord := range comb.OrdersList {
+		ids[i] = ord.Id
+	}
+	var orders []*pb.Order
+	var rejected []rejectedOrder
+	err := s.orders.UpdateAll(ids, func(current map[string]*pb.Order) ([]*pb.Order, error) {
+		orders, rejected = make([]*pb.Order, 0, len(ids)), nil
+		for _, id := range ids {
+			ord, exists := current[id]
+			switch {
+			case !exists:
+				rejected = append(rejected, rejectedOrder{id, rpcerr.NotFound(orderResource, id)})
+				continue
+			case ord.ShipmentId != "":
+				rejected = append(rejected, rejectedOrder{id, alreadyInShipment(ord)})
+				continue
+			case ord.Status != pb.OrderStatus_ORDER_STATUS_PROCESSING:
+				rejected = append(rejected, rejectedOrder{id, rpcerr.FailedPrecondition(rpcerr.ReasonInvalidTransition, orderResource, id,
+					map[string]string{"status": ord.Status.String()},
+					"Order %v is %v and can no longer be shipped", id, ord.Status)})
+				continue
+			}
+			updated := proto.Clone(ord).(*pb.Order)
+			updated.ShipmentId = comb.Id
+			updated.Version++
+			orders = append(orders, updated)
+		}
+		return orders, nil
+	})
+	if err != nil {
+		return nil, nil, storeError(err, ids[0])
+	}
+	if len(orders) == 0 {
+		return nil, rejected, nil
+	}
+
+	now := timestamppb.Now()
+	shipment := proto.Clone(comb).(*pb.CombinedShipment)
+	shipment.OrdersList = orders
+	if len(rejected) > 0 {
+		prices := make([]*moneypb.Money, len(orders))
+		for i, ord := range orders {
+			prices[i] = ord.Price
+		}
+		if shipment.Total, err = money.Sum(comb.Total.GetCurrencyCode(), prices...); err != nil {
+			return nil, nil, rpcerr.Internal("Combining shipment %v: %v", comb.Id, err)
+		}
+	}
+	shipment.Status = pb.ShipmentStatus_SHIPMENT_STATUS_PROCESSING
+	shipment.CreatedAt = now
+	shipment.Events = []*pb.ShipmentEvent{{Status: shipment.Status, Time: now}}
+	if err := s.shipments.Put(shipment); err != nil {
+		s.unlinkShipment(shipment.Id, ids)
+		return nil, nil, rpcerr.Internal("Saving shipment %v: %v", shipment.Id, err)
+	}
+	return shipment, rejected, nil
+}
+
+// alreadyInShipment reports that ord is linked to a shipment already.
+func alreadyInShipment(ord *pb.Order) error {
+	return rpcerr.FailedPrecondition(rpcerr.ReasonAlreadyInShipment, orderResource, ord.Id,
+		map[string]string{"shipment_id": ord.ShipmentId},
+		"Order %v is already in shipment %v", ord.Id, ord.ShipmentId)
+}
+
+// unlinkShipment clears the shipment of those orders with the given ids
+// that are linked to shipment id, so that they can be shipped again.
+func (s *Server) unlinkShipment(id string, ids []string) {
+	err := s.orders.UpdateAll(ids, func(current map[string]*pb.Order) ([]*pb.Order, error) {
+		var unlinked []*pb.Order
+		for _, ord := range current {
+			if ord.ShipmentId == id {
+				updated := proto.Clone(ord).(*pb.Order)
+				updated.ShipmentId = ""
+				updated.Version++
+				unlinked = append(unlinked, updated)
+			}
+		}
+		return unlinked, nil
+	})
+	if err != nil {
+		log.Printf("%v Unlinking the orders of shipment %v: %v\n", tag, id, err)
+	}
+}
+
+// unlinkLostShipments clears the shipment of every order linked to a
+// shipment that does not exist, e.g. because shipments are only kept in
+// memory with --store-file, so that they can be shipped again. It returns
+// the number of orders unlinked.
+func unlinkLostShipments(orders OrderStore, shipments ShipmentStore) (int, error) {
+	var ids []string
+	orders.Scan(func(ord *pb.Order) bool {
+		if _, exists := shipments.Get(ord.ShipmentId); ord.ShipmentId != "" && !exists {
+			ids = append(ids, ord.Id)
+		}
+		return true
+	})
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	n := 0
+	err := orders.UpdateAll(ids, func(current map[string]*pb.Order) ([]*pb.Order, error) {
+		var unlinked []*pb.Order
+		for _, ord := range current {
+			if _, exists := shipments.Get(ord.ShipmentId); ord.ShipmentId != "" && !exists {
+				updated := proto.Clone(ord).(*pb.Order)
+				updated.ShipmentId = ""
+				updated.Version++
+				unlinked = append(unlinked, updated)
+			}
+		}
+		n = len(unlinked)
+		return unlinked, nil
+	})
+	return n, err
+}
+
+// sendRejection tells the client that orderId was not added to any shipment
+// and why, without ending the stream.
+func sendRejection(stream pb.OrderManagement_ProcessOrdersServer, tag0 string, orderId string, reason error) error {
+	log.Printf("%v [Rejected] %v: %v\n", tag0, orderId, reason)
+	return stream.Send(&pb.ProcessResult{Result: &pb.ProcessResult_Rejection{Rejection: &pb.OrderRejection{
+		OrderId: orderId,
+		Status:  status.Convert(reason).Proto(),
+	}}})
+}
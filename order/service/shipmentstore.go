@@ -0,0 +1,144 @@
+package main
+
+import (
+	pb "ecommerce/order/proto"
+	"ecommerce/storage"
+	"google.golang.org/protobuf/proto"
+	"sync"
+)
+
+// shipmentResource is the resource type reported in error details.
+var shipmentResource = string((&pb.CombinedShipment{}).ProtoReflect().Descriptor().FullName())
+
+// ShipmentStore keeps the shipments made by ProcessOrders. Like OrderStore
+// it must be safe for concurrent use, and shipments handed to or returned
+// by it are treated as immutable.
+type ShipmentStore interface {
+	// Get returns the shipment with the given id and whether it exists.
+	Get(id string) (*pb.CombinedShipment, bool)
+	// Put inserts or replaces the shipment keyed by shipment.Id.
+	Put(shipment *pb.CombinedShipment) error
+	// Update atomically replaces the shipment with the given id by the
+	// result of fn, as OrderStore.Update does.
+	Update(id string, fn func(current *pb.CombinedShipment) (*pb.CombinedShipment, error)) (*pb.CombinedShipment, error)
+	// List returns every stored shipment.
+	List() []*pb.CombinedShipment
+}
+
+// memShipmentStore keeps shipments in a map guarded by a RWMutex.
+type memShipmentStore struct {
+	mu        sync.RWMutex
+	shipments map[string]*pb.CombinedShipment
+}
+
+func newMemShipmentStore() *memShipmentStore {
+	return &memShipmentStore{shipments: make(map[string]*pb.CombinedShipment)}
+}
+
+func (m *memShipmentStore) Get(id string) (*pb.CombinedShipment, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	shipment, exists := m.shipments[id]
+	return shipment, exists
+}
+
+func (m *memShipmentStore) Put(shipment *pb.CombinedShipment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shipments[shipment.Id] = shipment
+	return nil
+}
+
+func (m *memShipmentStore) Update(id string, fn func(current *pb.CombinedShipment) (*pb.CombinedShipment, error)) (*pb.CombinedShipment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	updated, err := fn(m.shipments[id])
+	if err != nil {
+		return nil, err
+	}
+	m.shipments[id] = updated
+	return updated, nil
+}
+
+func (m *memShipmentStore) List() []*pb.CombinedShipment {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	shipments := make([]*pb.CombinedShipment, 0, len(m.shipments))
+	for _, shipment := range m.shipments {
+		shipments = append(shipments, shipment)
+	}
+	return shipments
+}
+
+// durableShipmentStore is a ShipmentStore backed by the embedded storage
+// engine, in the same way as durableOrderStore.
+type durableShipmentStore struct {
+	mu  sync.Mutex
+	db  *storage.DB
+	mem *memShipmentStore
+}
+
+func openDurableShipmentStore(dir string) (*durableShipmentStore, error) {
+	db, err := storage.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &durableShipmentStore{db: db, mem: newMemShipmentStore()}
+	db.Range(func(_ string, value []byte) bool {
+		shipment := &pb.CombinedShipment{}
+		if err = proto.Unmarshal(value, shipment); err != nil {
+			return false
+		}
+		d.mem.shipments[shipment.Id] = shipment
+		return true
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *durableShipmentStore) Get(id string) (*pb.CombinedShipment, bool) {
+	return d.mem.Get(id)
+}
+
+func (d *durableShipmentStore) Put(shipment *pb.CombinedShipment) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.put(shipment)
+}
+
+func (d *durableShipmentStore) put(shipment *pb.CombinedShipment) error {
+	b, err := proto.Marshal(shipment)
+	if err != nil {
+		return err
+	}
+	if err := d.db.Put(shipment.Id, b); err != nil {
+		return err
+	}
+	return d.mem.Put(shipment)
+}
+
+func (d *durableShipmentStore) Update(id string, fn func(current *pb.CombinedShipment) (*pb.CombinedShipment, error)) (*pb.CombinedShipment, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current, _ := d.mem.Get(id)
+	updated, err := fn(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.put(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (d *durableShipmentStore) List() []*pb.CombinedShipment {
+	return d.mem.List()
+}
+
+func (d *durableShipmentStore) Close() error {
+	return d.db.Close()
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	pb "ecommerce/order/proto"
+	"ecommerce/rpcerr"
+	"google.golang.org/protobuf/proto"
+	"log"
+	"sync"
+)
+
+// orderTransitions lists, for each status, the statuses an order may move to.
+// SHIPPED orders can no longer be cancelled; DELIVERED and CANCELLED are final.
+var orderTransitions = map[pb.OrderStatus][]pb.OrderStatus{
+	pb.OrderStatus_ORDER_STATUS_PENDING: {
+		pb.OrderStatus_ORDER_STATUS_CONFIRMED,
+		pb.OrderStatus_ORDER_STATUS_PROCESSING,
+		pb.OrderStatus_ORDER_STATUS_CANCELLED,
+	},
+	pb.OrderStatus_ORDER_STATUS_CONFIRMED: {
+		pb.OrderStatus_ORDER_STATUS_PROCESSING,
+		pb.OrderStatus_ORDER_STATUS_CANCELLED,
+	},
+	pb.OrderStatus_ORDER_STATUS_PROCESSING: {
+		pb.OrderStatus_ORDER_STATUS_SHIPPED,
+		pb.OrderStatus_ORDER_STATUS_CANCELLED,
+	},
+	pb.OrderStatus_ORDER_STATUS_SHIPPED: {
+		pb.OrderStatus_ORDER_STATUS_DELIVERED,
+	},
+}
+
+func canTransition(from, to pb.OrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// isEditable reports whether the contents of an order in this status may
+// still be replaced through UpdateOrders.
+func isEditable(st pb.OrderStatus) bool {
+	switch st {
+	case pb.OrderStatus_ORDER_STATUS_PENDING,
+		pb.OrderStatus_ORDER_STATUS_CONFIRMED,
+		pb.OrderStatus_ORDER_STATUS_PROCESSING:
+		return true
+	}
+	return false
+}
+
+// startOrder sets the status of a new order, which always starts PENDING.
+// It fails with InvalidArgument if the client asked for another status, as
+// orders only get there through transitions.
+func startOrder(ord *pb.Order) error {
+	switch ord.Status {
+	case pb.OrderStatus_ORDER_STATUS_UNSPECIFIED:
+		ord.Status = pb.OrderStatus_ORDER_STATUS_PENDING
+	case pb.OrderStatus_ORDER_STATUS_PENDING:
+	default:
+		return rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, "status", "New orders start as %v, got %v", pb.OrderStatus_ORDER_STATUS_PENDING, ord.Status)
+	}
+	return nil
+}
+
+// transitionOrder atomically moves the order to status to,
+// failing with FailedPrecondition if the state machine forbids it.
+func (s *Server) transitionOrder(id string, to pb.OrderStatus) (*pb.Order, error) {
+	ord, err := s.orders.Update(id, func(current *pb.Order) (*pb.Order, error) {
+		if current == nil {
+			return nil, rpcerr.NotFound(orderResource, id)
+		}
+		if !canTransition(current.Status, to) {
+			return nil, rpcerr.FailedPrecondition(rpcerr.ReasonInvalidTransition, orderResource, id,
+				map[string]string{"from": current.Status.String(), "to": to.String()},
+				"Order %v cannot move from %v to %v", id, current.Status, to)
+		}
+		updated := proto.Clone(current).(*pb.Order)
+		updated.Status = to
+		updated.Version++
+		return updated, nil
+	})
+	if err != nil {
+		return nil, storeError(err, id)
+	}
+	return ord, nil
+}
+
+// transition moves the order to status to like transitionOrder, and settles
+// the stock reserved for its line items: the reservation is committed before
+// the order starts PROCESSING, failing the transition if that is not
+// possible, and released once the order is CANCELLED. The shipment of the
+// order, if any, then follows the new status.
+//
+// Transitions of the same order are serialized, so that no other one gets
+// between committing the reservation and the status change.
+func (s *Server) transition(ctx context.Context, tag0, id string, to pb.OrderStatus) (*pb.Order, error) {
+	defer s.transitions.lock(id)()
+
+	if to == pb.OrderStatus_ORDER_STATUS_PROCESSING {
+		current, exists := s.orders.Get(id)
+		if exists && current.ReservationId != "" && canTransition(current.Status, to) {
+			if err := s.stock.Commit(ctx, current.ReservationId); err != nil {
+				return nil, err
+			}
+		}
+	}
+	ord, err := s.transitionOrder(id, to)
+	if err != nil {
+		if to == pb.OrderStatus_ORDER_STATUS_PROCESSING {
+			// Only the store can fail here, the transition was checked above.
+			log.Printf("%v Order %v reservation committed, but not moved to %v: %v\n", tag0, id, to, err)
+		}
+		return nil, err
+	}
+	if to == pb.OrderStatus_ORDER_STATUS_CANCELLED {
+		s.releaseStock(tag0, ord)
+	}
+	if ord.ShipmentId != "" {
+		s.refreshShipment(tag0, ord.ShipmentId)
+	}
+	return ord, nil
+}
+
+// keyedMutex locks keys, e.g. order ids, independently of each other.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	waiters int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedLock)}
+}
+
+// lock locks key and returns the function unlocking it. Locks of keys nobody
+// holds or waits for are dropped.
+func (k *keyedMutex) lock(key string) func() {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.waiters++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		if l.waiters--; l.waiters == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// releaseStock returns the stock reserved for ord, if any. A failure is only
+// logged: the order is not to be held up by it, and a reservation that was
+// already committed stays so.
+func (s *Server) releaseStock(tag0 string, ord *pb.Order) {
+	if ord.ReservationId == "" {
+		return
+	}
+	if err := s.stock.Release(context.Background(), ord.ReservationId); err != nil {
+		log.Printf("%v Releasing reservation %v of order %v: %v\n", tag0, ord.ReservationId, ord.Id, err)
+	}
+}
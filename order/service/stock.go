@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	inventorypb "ecommerce/inventory/proto"
+	pb "ecommerce/order/proto"
+	"ecommerce/rpcerr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// inventoryService names the Inventory service in dependency errors.
+const inventoryService = "inventory.Inventory"
+
+// Stock holds the stock of the products ordered through line items, from
+// the time an order is placed until it is processed or cancelled.
+type Stock interface {
+	// Reserve holds the stock of items under reservationId, all of them or
+	// none. It fails with codes.FailedPrecondition if a product is out of
+	// stock. Reserving the same id again has no effect.
+	Reserve(ctx context.Context, reservationId string, items []*pb.LineItem) error
+	// Commit consumes the stock held by a reservation.
+	Commit(ctx context.Context, reservationId string) error
+	// Release returns the stock held by a reservation.
+	Release(ctx context.Context, reservationId string) error
+}
+
+// inventoryStock is the Stock kept by the Inventory service.
+type inventoryStock struct {
+	client inventorypb.InventoryClient
+}
+
+func (s *inventoryStock) Reserve(ctx context.Context, reservationId string, items []*pb.LineItem) error {
+	req := &inventorypb.ReserveRequest{ReservationId: reservationId}
+	for _, item := range items {
+		req.Items = append(req.Items, &inventorypb.ReservedItem{ProductId: item.ProductId, Quantity: int64(item.Quantity)})
+	}
+	_, err := s.client.Reserve(ctx, req)
+	return inventoryError(err)
+}
+
+func (s *inventoryStock) Commit(ctx context.Context, reservationId string) error {
+	_, err := s.client.Commit(ctx, &inventorypb.ReservationId{Id: reservationId})
+	return inventoryError(err)
+}
+
+func (s *inventoryStock) Release(ctx context.Context, reservationId string) error {
+	_, err := s.client.Release(ctx, &inventorypb.ReservationId{Id: reservationId})
+	return inventoryError(err)
+}
+
+// inventoryError passes on the errors the Inventory service answered on
+// purpose, such as an out of stock product, with their details, and reports
+// any other failure as a dependency error.
+func inventoryError(err error) error {
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.FailedPrecondition, codes.NotFound, codes.InvalidArgument:
+		return err
+	}
+	return rpcerr.DependencyFailed(inventoryService, err)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	pb "ecommerce/order/proto"
+	"ecommerce/rpcerr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+// soldOutStock is a Stock without any units left.
+type soldOutStock struct{ *fakeStock }
+
+func (soldOutStock) Reserve(_ context.Context, id string, items []*pb.LineItem) error {
+	return rpcerr.FailedPrecondition(rpcerr.ReasonOutOfStock, "inventory.Product", items[0].ProductId, nil,
+		"Product %v is out of stock", items[0].ProductId)
+}
+
+// slowCommitStock is a Stock whose commits wait for proceed, after telling
+// committing that they started.
+type slowCommitStock struct {
+	*fakeStock
+	committing, proceed chan struct{}
+}
+
+func (s slowCommitStock) Commit(ctx context.Context, id string) error {
+	s.committing <- struct{}{}
+	<-s.proceed
+	return s.fakeStock.Commit(ctx, id)
+}
+
+func TestReservationFollowsOrder(t *testing.T) {
+	stock := newFakeStock()
+	client := startServer(t, newMemOrderStore(), testCatalog, stock)
+	ctx := context.Background()
+	reservation := func(id string) string {
+		t.Helper()
+		ord, err := client.GetOrder(ctx, &pb.OrderId{Id: id})
+		if err != nil {
+			t.Fatalf("getOrder %v: %v", id, err)
+		}
+		return ord.ReservationId
+	}
+	for _, id := range []string{"o1", "o2"} {
+		_, err := client.AddOrder(ctx, &pb.Order{Id: id, Destination: sanJose, LineItems: []*pb.LineItem{{ProductId: "p1", Quantity: 1}}})
+		if err != nil {
+			t.Fatalf("addOrder %v: %v", id, err)
+		}
+	}
+	shipped, cancelled := reservation("o1"), reservation("o2")
+	if shipped == "" || cancelled == "" || stock.state(shipped) != "reserved" || stock.state(cancelled) != "reserved" {
+		t.Fatalf("reservations %q and %q are %q and %q, want reserved", shipped, cancelled, stock.state(shipped), stock.state(cancelled))
+	}
+
+	// Processing an order consumes its stock, cancelling it returns the stock.
+	send, _, closeSend := processOrders(t, client)
+	send("o1")
+	checkResults(t, closeSend(), sanJose+": o1")
+	if _, err := client.CancelOrder(ctx, &pb.OrderId{Id: "o2"}); err != nil {
+		t.Fatalf("cancelOrder: %v", err)
+	}
+	if stock.state(shipped) != "committed" || stock.state(cancelled) != "released" {
+		t.Errorf("reservations are %q and %q, want committed and released", stock.state(shipped), stock.state(cancelled))
+	}
+
+	// Orders without line items reserve nothing.
+	if _, err := client.AddOrder(ctx, newOrder("o3", sanJose)); err != nil {
+		t.Fatalf("addOrder: %v", err)
+	}
+	if id := reservation("o3"); id != "" {
+		t.Errorf("order without line items has reservation %q", id)
+	}
+}
+
+func TestAddOrderOutOfStock(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, soldOutStock{newFakeStock()})
+	ctx := context.Background()
+	_, err := client.AddOrder(ctx, &pb.Order{Id: "o1", Destination: sanJose, LineItems: []*pb.LineItem{{ProductId: "p1", Quantity: 1}}})
+	if status.Code(err) != codes.FailedPrecondition || rpcerr.Reason(err) != rpcerr.ReasonOutOfStock {
+		t.Fatalf("addOrder = %v, want FailedPrecondition (%v)", err, rpcerr.ReasonOutOfStock)
+	}
+	if _, err := client.GetOrder(ctx, &pb.OrderId{Id: "o1"}); status.Code(err) != codes.NotFound {
+		t.Errorf("getOrder of the order out of stock = %v, want NotFound", err)
+	}
+}
+
+func TestCancelWaitsForCommit(t *testing.T) {
+	stock := slowCommitStock{newFakeStock(), make(chan struct{}), make(chan struct{})}
+	client := startServer(t, newMemOrderStore(), testCatalog, stock)
+	ctx := context.Background()
+	if _, err := client.AddOrder(ctx, &pb.Order{Id: "o1", Destination: sanJose, LineItems: []*pb.LineItem{{ProductId: "p1", Quantity: 1}}}); err != nil {
+		t.Fatalf("addOrder: %v", err)
+	}
+
+	// A cancellation arriving while the reservation is being committed waits
+	// for the order to start PROCESSING, rather than cancelling the order
+	// whose stock is about to be consumed.
+	send, _, closeSend := processOrders(t, client)
+	send("o1")
+	<-stock.committing
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := client.CancelOrder(ctx, &pb.OrderId{Id: "o1"})
+		cancelled <- err
+	}()
+	select {
+	case err := <-cancelled:
+		t.Fatalf("cancelOrder returned %v during the commit", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(stock.proceed)
+	if err := <-cancelled; err != nil {
+		t.Fatalf("cancelOrder: %v", err)
+	}
+	// Cancelled before its shipment went out, the order is left out of it.
+	checkResults(t, closeSend(), "rejected o1: FailedPrecondition")
+	ord, err := client.GetOrder(ctx, &pb.OrderId{Id: "o1"})
+	if err != nil {
+		t.Fatalf("getOrder: %v", err)
+	}
+	if ord.Status != pb.OrderStatus_ORDER_STATUS_CANCELLED || stock.state(ord.ReservationId) != "committed" {
+		t.Errorf("order is %v with reservation %v, want CANCELLED with committed", ord.Status, stock.state(ord.ReservationId))
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	"ecommerce/rpcerr"
+	"google.golang.org/grpc/status"
+	"sync"
+)
+
+// orderResource is the resource type reported in error details.
+var orderResource = string((&pb.Order{}).ProtoReflect().Descriptor().FullName())
+
+// OrderStore is the persistence backend used by Server.
+// Handlers only talk to this interface, so the same RPC logic runs
+// against the in-memory map, a file, or a fake in tests.
+//
+// Implementations must be safe for concurrent use by the RPC goroutines.
+// Orders handed to or returned by a store are shared, so callers treat
+// them as immutable and Put a modified copy instead.
+type OrderStore interface {
+	// Get returns the order with the given id and whether it exists.
+	Get(id string) (*pb.Order, bool)
+	// Put inserts or replaces the order keyed by order.Id.
+	Put(order *pb.Order) error
+	// Update atomically replaces the order with the given id by the result
+	// of fn. fn receives nil when the order does not exist; if it returns an
+	// error nothing is written and the error is passed through.
+	Update(id string, fn func(current *pb.Order) (*pb.Order, error)) (*pb.Order, error)
+	// UpdateAll is the multi-order form of Update: fn receives the current
+	// orders with the given ids, keyed by id, and returns the orders to write.
+	// Either all of them are written or, if fn or the backend fails, none.
+	UpdateAll(ids []string, fn func(current map[string]*pb.Order) ([]*pb.Order, error)) error
+	// List returns every stored order.
+	List() []*pb.Order
+	// Delete removes the order with the given id, if present.
+	Delete(id string) error
+	// Scan calls fn for each order of a consistent snapshot of the store
+	// until fn returns false. Writes made while scanning are not observed.
+	Scan(fn func(order *pb.Order) bool)
+}
+
+// migrateOrder converts the float price of an order stored before prices
+// were money amounts, which were always in USD.
+func migrateOrder(ord *pb.Order) {
+	if ord.Price == nil && ord.LegacyPrice != 0 {
+		ord.Price = money.FromFloat("USD", float64(ord.LegacyPrice))
+		ord.LegacyPrice = 0
+	}
+}
+
+// storeError passes through the gRPC status errors returned by Update
+// callbacks and reports anything else, i.e. a failing backend, as Internal.
+func storeError(err error, id string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return rpcerr.Internal("Saving order %v: %v", id, err)
+}
+
+// checkVersion implements optimistic concurrency control: it fails with
+// Aborted when the caller expected another version of the order than the
+// stored one. An expected version of 0 skips the check.
+func checkVersion(id string, expected int64, current *pb.Order) error {
+	if expected == 0 {
+		return nil
+	}
+	if current == nil {
+		return rpcerr.NotFound(orderResource, id)
+	}
+	if current.Version != expected {
+		return rpcerr.VersionMismatch(orderResource, id, current.Version, expected)
+	}
+	return nil
+}
+
+// memOrderStore keeps orders in a map guarded by a RWMutex.
+type memOrderStore struct {
+	mu     sync.RWMutex
+	orders map[string]*pb.Order
+}
+
+func newMemOrderStore() *memOrderStore {
+	return &memOrderStore{orders: make(map[string]*pb.Order)}
+}
+
+func (m *memOrderStore) Get(id string) (*pb.Order, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ord, exists := m.orders[id]
+	return ord, exists
+}
+
+func (m *memOrderStore) Put(order *pb.Order) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders[order.Id] = order
+	return nil
+}
+
+func (m *memOrderStore) Update(id string, fn func(current *pb.Order) (*pb.Order, error)) (*pb.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	updated, err := fn(m.orders[id])
+	if err != nil {
+		return nil, err
+	}
+	m.orders[id] = updated
+	return updated, nil
+}
+
+func (m *memOrderStore) UpdateAll(ids []string, fn func(current map[string]*pb.Order) ([]*pb.Order, error)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	updated, err := fn(m.lookupLocked(ids))
+	if err != nil {
+		return err
+	}
+	for _, ord := range updated {
+		m.orders[ord.Id] = ord
+	}
+	return nil
+}
+
+func (m *memOrderStore) lookupLocked(ids []string) map[string]*pb.Order {
+	current := make(map[string]*pb.Order, len(ids))
+	for _, id := range ids {
+		if ord, exists := m.orders[id]; exists {
+			current[id] = ord
+		}
+	}
+	return current
+}
+
+func (m *memOrderStore) List() []*pb.Order {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	orders := make([]*pb.Order, 0, len(m.orders))
+	for _, ord := range m.orders {
+		orders = append(orders, ord)
+	}
+	return orders
+}
+
+func (m *memOrderStore) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.orders, id)
+	return nil
+}
+
+func (m *memOrderStore) Scan(fn func(order *pb.Order) bool) {
+	// Iterate over a copy so fn runs without holding the lock
+	// and concurrent writers are not blocked behind a slow stream.
+	for _, ord := range m.List() {
+		if !fn(ord) {
+			return
+		}
+	}
+}
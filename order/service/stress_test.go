@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	pb "ecommerce/order/proto"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// orderIds is the growing set of ids of the orders placed by a test.
+type orderIds struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (o *orderIds) add(id string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.ids = append(o.ids, id)
+}
+
+func (o *orderIds) pick(r *rand.Rand) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.ids[r.Intn(len(o.ids))]
+}
+
+func (o *orderIds) all() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.ids...)
+}
+
+// TestConcurrentCalls places, reads, searches, updates, processes and
+// cancels orders from many goroutines at once, against every kind of store,
+// then checks that no order was lost and that the stock reservations follow
+// the orders. Run it with -race.
+func TestConcurrentCalls(t *testing.T) {
+	stores := map[string]func(t *testing.T) OrderStore{
+		"memory": func(t *testing.T) OrderStore { return newMemOrderStore() },
+		"file": func(t *testing.T) OrderStore {
+			f, err := openFileOrderStore(filepath.Join(t.TempDir(), "orders.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return f
+		},
+		"durable": func(t *testing.T) OrderStore {
+			d, err := openDurableOrderStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { d.Close() })
+			return d
+		},
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			orders, stock := open(t), newFakeStock()
+			testConcurrentCalls(t, startServer(t, orders, testCatalog, stock), orders, stock)
+		})
+	}
+}
+
+func testConcurrentCalls(t *testing.T, client pb.OrderManagementClient, orders OrderStore, stock *fakeStock) {
+	const (
+		workers    = 4  // goroutines per method
+		iterations = 25 // calls per goroutine
+	)
+	ctx := context.Background()
+
+	var ids orderIds
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("seed-%d", i)
+		ord := &pb.Order{Id: id, Destination: "Mountain View, CA", LineItems: []*pb.LineItem{{ProductId: "p1", Quantity: 1}}}
+		if _, err := client.AddOrder(ctx, ord); err != nil {
+			t.Fatalf("addOrder: %v", err)
+		}
+		ids.add(id)
+	}
+
+	destinations := []string{"Mountain View, CA", "San Jose, CA"}
+	calls := map[string]func(r *rand.Rand) error{
+		"addOrder": func(r *rand.Rand) error {
+			ord := &pb.Order{
+				Id:          fmt.Sprintf("order-%d", r.Int63()),
+				Destination: destinations[r.Intn(len(destinations))],
+				LineItems:   []*pb.LineItem{{ProductId: "p2", Quantity: int32(1 + r.Intn(3))}},
+			}
+			if _, err := client.AddOrder(ctx, ord); err != nil {
+				return err
+			}
+			ids.add(ord.Id)
+			return nil
+		},
+		"getOrder": func(r *rand.Rand) error {
+			_, err := client.GetOrder(ctx, &pb.OrderId{Id: ids.pick(r)})
+			return err
+		},
+		"searchOrders": func(r *rand.Rand) error {
+			stream, err := client.SearchOrders(ctx, &pb.SearchRequest{S: "Apple"})
+			if err != nil {
+				return err
+			}
+			for {
+				if _, err := stream.Recv(); err == io.EOF {
+					return nil
+				} else if err != nil {
+					return err
+				}
+			}
+		},
+		"updateOrders": func(r *rand.Rand) error {
+			stream, err := client.UpdateOrders(ctx)
+			if err != nil {
+				return err
+			}
+			for i := 0; i < 3; i++ {
+				ord, err := client.GetOrder(ctx, &pb.OrderId{Id: ids.pick(r)})
+				if err != nil {
+					return err
+				}
+				ord.Description = fmt.Sprintf("update %d", r.Int())
+				if err := stream.Send(ord); err != nil {
+					return err
+				}
+			}
+			resp, err := stream.CloseAndRecv()
+			if err != nil {
+				return err
+			}
+			for _, res := range resp.Results {
+				// The order may have changed, or been cancelled, since it was read.
+				if c := codes.Code(res.Status.GetCode()); res.Outcome == pb.UpdateOutcome_UPDATE_OUTCOME_REJECTED && c != codes.Aborted && c != codes.FailedPrecondition {
+					return fmt.Errorf("update of %v rejected: %v", res.Id, res.Status.Message)
+				}
+			}
+			return nil
+		},
+		"cancelOrder": func(r *rand.Rand) error {
+			// Orders past PROCESSING can no longer be cancelled.
+			if _, err := client.CancelOrder(ctx, &pb.OrderId{Id: ids.pick(r)}); status.Code(err) != codes.FailedPrecondition {
+				return err
+			}
+			return nil
+		},
+		"processOrders": func(r *rand.Rand) error {
+			stream, err := client.ProcessOrders(ctx)
+			if err != nil {
+				return err
+			}
+			for i := 0; i < 2; i++ {
+				if err := stream.Send(&pb.OrderId{Id: ids.pick(r)}); err != nil {
+					return err
+				}
+			}
+			if err := stream.CloseSend(); err != nil {
+				return err
+			}
+			handled := 0
+			for {
+				res, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return err
+				}
+				// Another stream may have shipped or cancelled the order first.
+				if rej := res.GetRejection(); rej != nil && codes.Code(rej.Status.Code) != codes.FailedPrecondition {
+					return fmt.Errorf("order %v rejected: %v", rej.OrderId, rej.Status.Message)
+				}
+				if res.GetRejection() != nil {
+					handled++
+				}
+				handled += len(res.GetShipment().GetOrdersList())
+			}
+			if handled != 2 {
+				return fmt.Errorf("%d orders shipped or rejected, want 2", handled)
+			}
+			return nil
+		},
+	}
+
+	var wg sync.WaitGroup
+	seed := int64(0)
+	for method, call := range calls {
+		for w := 0; w < workers; w++ {
+			seed++
+			wg.Add(1)
+			go func(method string, call func(r *rand.Rand) error, r *rand.Rand) {
+				defer wg.Done()
+				for i := 0; i < iterations; i++ {
+					if err := call(r); err != nil {
+						t.Errorf("%v: %v", method, status.Convert(err).Message())
+						return
+					}
+				}
+			}(method, call, rand.New(rand.NewSource(seed)))
+		}
+	}
+	wg.Wait()
+
+	all := ids.all()
+	for _, id := range all {
+		ord, ok := orders.Get(id)
+		if !ok {
+			t.Errorf("order %v is missing", id)
+			continue
+		}
+		want := []string{"reserved"}
+		switch ord.Status {
+		case pb.OrderStatus_ORDER_STATUS_PROCESSING:
+			want = []string{"committed"}
+		case pb.OrderStatus_ORDER_STATUS_CANCELLED:
+			// Orders cancelled while PROCESSING keep their committed stock.
+			want = []string{"released", "committed"}
+		}
+		got, ok := stock.state(ord.ReservationId), false
+		for _, w := range want {
+			ok = ok || got == w
+		}
+		if !ok {
+			t.Errorf("order %v is %v with reservation %v %v, want %v", id, ord.Status, ord.ReservationId, got, strings.Join(want, " or "))
+		}
+	}
+	if n := len(orders.List()); n != len(all) {
+		t.Errorf("%d orders stored, want %d", n, len(all))
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	pb "ecommerce/order/proto"
+	"ecommerce/rpcerr"
+	"encoding/base64"
+	"encoding/json"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultShipmentPageSize = 50
+	maxShipmentPageSize     = 1000
+)
+
+// shipmentStatus derives the status of a shipment from its orders, see
+// pb.ShipmentStatus.
+func shipmentStatus(orders []*pb.Order) pb.ShipmentStatus {
+	active, shipped, delivered := 0, 0, 0
+	for _, ord := range orders {
+		switch ord.Status {
+		case pb.OrderStatus_ORDER_STATUS_CANCELLED:
+			continue
+		case pb.OrderStatus_ORDER_STATUS_DELIVERED:
+			delivered++
+			shipped++
+		case pb.OrderStatus_ORDER_STATUS_SHIPPED:
+			shipped++
+		}
+		active++
+	}
+	switch {
+	case active == 0:
+		return pb.ShipmentStatus_SHIPMENT_STATUS_CANCELLED
+	case delivered == active:
+		return pb.ShipmentStatus_SHIPMENT_STATUS_DELIVERED
+	case shipped == active:
+		return pb.ShipmentStatus_SHIPMENT_STATUS_IN_TRANSIT
+	}
+	return pb.ShipmentStatus_SHIPMENT_STATUS_PROCESSING
+}
+
+// isFinalShipmentStatus reports whether a shipment in this status no longer changes.
+func isFinalShipmentStatus(st pb.ShipmentStatus) bool {
+	return st == pb.ShipmentStatus_SHIPMENT_STATUS_DELIVERED || st == pb.ShipmentStatus_SHIPMENT_STATUS_CANCELLED
+}
+
+// refreshShipment brings the orders of a shipment up to date after one of
+// them changed status and records the resulting status change, if any,
+// waking up the streams tracking the shipment. Failures are only logged:
+// the order transition that triggered the refresh has happened already.
+func (s *Server) refreshShipment(tag0, id string) {
+	changed := false
+	_, err := s.shipments.Update(id, func(current *pb.CombinedShipment) (*pb.CombinedShipment, error) {
+		if current == nil {
+			return nil, rpcerr.NotFound(shipmentResource, id)
+		}
+		updated := proto.Clone(current).(*pb.CombinedShipment)
+		for i, ord := range updated.OrdersList {
+			if latest, exists := s.orders.Get(ord.Id); exists {
+				updated.OrdersList[i] = latest
+			}
+		}
+		if st := shipmentStatus(updated.OrdersList); st != updated.Status {
+			updated.Status = st
+			updated.Events = append(updated.Events, &pb.ShipmentEvent{Status: st, Time: timestamppb.Now()})
+			changed = true
+		}
+		return updated, nil
+	})
+	if err != nil {
+		log.Printf("%v Refreshing shipment %v: %v\n", tag0, id, err)
+		return
+	}
+	if changed {
+		s.trackers.notify(id)
+	}
+}
+
+// shipmentTrackers wakes up the TrackShipment streams of a shipment when its
+// status changes. Streams read the shipment back from the store when woken,
+// so a notification only needs to say that something changed.
+type shipmentTrackers struct {
+	mu      sync.Mutex
+	streams map[string]map[chan struct{}]bool
+}
+
+func newShipmentTrackers() *shipmentTrackers {
+	return &shipmentTrackers{streams: make(map[string]map[chan struct{}]bool)}
+}
+
+// track returns a channel that receives a value after the shipment with the
+// given id changed, and a function to stop tracking it.
+func (t *shipmentTrackers) track(id string) (<-chan struct{}, func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	if t.streams[id] == nil {
+		t.streams[id] = make(map[chan struct{}]bool)
+	}
+	t.streams[id][ch] = true
+	return ch, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.streams[id], ch)
+		if len(t.streams[id]) == 0 {
+			delete(t.streams, id)
+		}
+	}
+}
+
+func (t *shipmentTrackers) notify(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ch := range t.streams[id] {
+		// A pending notification already covers this change.
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// GetShipment Simple RPC
+func (s *Server) GetShipment(_ context.Context, shipmentId *pb.ShipmentId) (*pb.CombinedShipment, error) {
+	shipment, exists := s.shipments.Get(shipmentId.Id)
+	if exists {
+		return shipment, status.New(codes.OK, "").Err()
+	}
+
+	return nil, rpcerr.NotFound(shipmentResource, shipmentId.Id)
+}
+
+// shipmentPageToken is the decoded form of ListShipmentsResponse.next_page_token.
+// It holds the creation time and id of the last shipment returned.
+type shipmentPageToken struct {
+	Seconds int64  `json:"s"`
+	Nanos   int32  `json:"n"`
+	Id      string `json:"i"`
+}
+
+func (t *shipmentPageToken) encode() string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeShipmentPageToken(s string) (*shipmentPageToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	t := &shipmentPageToken{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// shipmentAddress returns the address of shipment, parsed from its
+// destination for shipments stored without address.
+func shipmentAddress(shipment *pb.CombinedShipment) *pb.Address {
+	if shipment.Address != nil {
+		return shipment.Address
+	}
+	return parseDestination(shipment.Destination)
+}
+
+// compareShipments orders shipments oldest first, breaking ties by id.
+func compareShipments(a, b *pb.CombinedShipment) int {
+	at, bt := a.CreatedAt.AsTime(), b.CreatedAt.AsTime()
+	switch {
+	case at.Before(bt):
+		return -1
+	case at.After(bt):
+		return 1
+	}
+	return strings.Compare(a.Id, b.Id)
+}
+
+// ListShipments Simple RPC
+func (s *Server) ListShipments(_ context.Context, req *pb.ListShipmentsRequest) (*pb.ListShipmentsResponse, error) {
+	pageSize := int(req.PageSize)
+	switch {
+	case pageSize == 0:
+		pageSize = defaultShipmentPageSize
+	case pageSize > maxShipmentPageSize:
+		pageSize = maxShipmentPageSize
+	}
+
+	var after *pb.CombinedShipment
+	if req.PageToken != "" {
+		token, err := decodeShipmentPageToken(req.PageToken)
+		if err != nil {
+			return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidPageToken, "page_token", "invalid page_token")
+		}
+		after = &pb.CombinedShipment{Id: token.Id, CreatedAt: &timestamppb.Timestamp{Seconds: token.Seconds, Nanos: token.Nanos}}
+	}
+
+	destination := addressKey(parseDestination(req.Destination))
+	var shipments []*pb.CombinedShipment
+	for _, shipment := range s.shipments.List() {
+		if req.Status != pb.ShipmentStatus_SHIPMENT_STATUS_UNSPECIFIED && shipment.Status != req.Status {
+			continue
+		}
+		if req.Destination != "" && addressKey(shipmentAddress(shipment)) != destination {
+			continue
+		}
+		if after == nil || compareShipments(shipment, after) > 0 {
+			shipments = append(shipments, shipment)
+		}
+	}
+	sort.Slice(shipments, func(i, j int) bool { return compareShipments(shipments[i], shipments[j]) < 0 })
+
+	resp := &pb.ListShipmentsResponse{}
+	if len(shipments) > pageSize {
+		shipments = shipments[:pageSize]
+		last := shipments[pageSize-1]
+		resp.NextPageToken = (&shipmentPageToken{
+			Seconds: last.CreatedAt.GetSeconds(),
+			Nanos:   last.CreatedAt.GetNanos(),
+			Id:      last.Id,
+		}).encode()
+	}
+	resp.Shipments = shipments
+
+	return resp, status.New(codes.OK, "").Err()
+}
+
+// TrackShipment Server Streaming RPC
+func (s *Server) TrackShipment(shipmentId *pb.ShipmentId, stream pb.OrderManagement_TrackShipmentServer) error {
+	tag0 := tag + " [TS]"
+
+	// Track before the first read, so that no change goes unnoticed.
+	changed, stop := s.trackers.track(shipmentId.Id)
+	defer stop()
+
+	sent := 0
+	for {
+		shipment, exists := s.shipments.Get(shipmentId.Id)
+		if !exists {
+			return rpcerr.NotFound(shipmentResource, shipmentId.Id)
+		}
+		if len(shipment.Events) > sent {
+			log.Printf("%v [Send] %v %v\n", tag0, shipment.Id, shipment.Status)
+			if err := stream.Send(shipment); err != nil {
+				return err
+			}
+			sent = len(shipment.Events)
+		}
+		if isFinalShipmentStatus(shipment.Status) {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+// shipOrders processes the orders with the given ids in a stream of their
+// own and returns the id of the shipment of the first one.
+func shipOrders(t *testing.T, client pb.OrderManagementClient, ids ...string) string {
+	t.Helper()
+	send, _, closeSend := processOrders(t, client)
+	send(ids...)
+	closeSend()
+	ord, err := client.GetOrder(context.Background(), &pb.OrderId{Id: ids[0]})
+	if err != nil {
+		t.Fatalf("getOrder %v: %v", ids[0], err)
+	}
+	return ord.ShipmentId
+}
+
+func TestTrackShipment(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	placeOrders(t, client, 400, "o1", sanJose, "o2", sanJose, "o3", sanJose)
+	id := shipOrders(t, client, "o1", "o2", "o3")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.TrackShipment(ctx, &pb.ShipmentId{Id: id})
+	if err != nil {
+		t.Fatalf("trackShipment: %v", err)
+	}
+	next := func(want pb.ShipmentStatus) {
+		t.Helper()
+		shipment, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("recv: %v", err)
+		}
+		if shipment.Id != id || shipment.Status != want || len(shipment.Events) == 0 || shipment.Events[len(shipment.Events)-1].Status != want {
+			t.Fatalf("shipment %v is %v after %d events, want %v %v", shipment.Id, shipment.Status, len(shipment.Events), id, want)
+		}
+	}
+	transition := func(id string, to pb.OrderStatus) {
+		t.Helper()
+		if _, err := client.TransitionOrder(ctx, &pb.TransitionRequest{Id: id, Status: to}); err != nil {
+			t.Fatalf("transitionOrder %v to %v: %v", id, to, err)
+		}
+	}
+	next(pb.ShipmentStatus_SHIPMENT_STATUS_PROCESSING)
+
+	// A cancelled order no longer holds the shipment up.
+	if _, err := client.CancelOrder(ctx, &pb.OrderId{Id: "o3"}); err != nil {
+		t.Fatalf("cancelOrder: %v", err)
+	}
+	transition("o1", pb.OrderStatus_ORDER_STATUS_SHIPPED)
+	transition("o2", pb.OrderStatus_ORDER_STATUS_SHIPPED)
+	next(pb.ShipmentStatus_SHIPMENT_STATUS_IN_TRANSIT)
+	transition("o1", pb.OrderStatus_ORDER_STATUS_DELIVERED)
+	transition("o2", pb.OrderStatus_ORDER_STATUS_DELIVERED)
+	next(pb.ShipmentStatus_SHIPMENT_STATUS_DELIVERED)
+	if _, err := stream.Recv(); err == nil {
+		t.Error("stream goes on after the shipment was delivered")
+	}
+
+	if stream, err = client.TrackShipment(ctx, &pb.ShipmentId{Id: "missing"}); err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("tracking a missing shipment = %v, want NotFound", err)
+	}
+}
+
+func TestListShipments(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	placeOrders(t, client, 400, "o1", sanJose, "o2", mountainView, "o3", sanJose, "o4", mountainView, "o5", sanJose)
+	var ids []string
+	for _, id := range []string{"o1", "o2", "o3", "o4", "o5"} {
+		ids = append(ids, shipOrders(t, client, id))
+	}
+	ctx := context.Background()
+
+	// Pages follow the creation order of the shipments.
+	var got []string
+	req := &pb.ListShipmentsRequest{PageSize: 2}
+	for {
+		resp, err := client.ListShipments(ctx, req)
+		if err != nil {
+			t.Fatalf("listShipments: %v", err)
+		}
+		for _, shipment := range resp.Shipments {
+			got = append(got, shipment.Id)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	checkResults(t, got, ids...)
+
+	resp, err := client.ListShipments(ctx, &pb.ListShipmentsRequest{Destination: mountainView})
+	if err != nil {
+		t.Fatalf("listShipments: %v", err)
+	}
+	got = nil
+	for _, shipment := range resp.Shipments {
+		got = append(got, shipment.Id)
+	}
+	checkResults(t, got, ids[1], ids[3])
+
+	if _, err := client.ListShipments(ctx, &pb.ListShipmentsRequest{PageToken: "garbage"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("listShipments with a bad page token = %v, want InvalidArgument", err)
+	}
+	if _, err := client.GetShipment(ctx, &pb.ShipmentId{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("getShipment of a missing shipment = %v, want NotFound", err)
+	}
+}
+
+func TestShipmentLeavesOutOrdersCancelledMeanwhile(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	placeOrders(t, client, 400, "o1", sanJose, "o2", sanJose, "o3", sanJose)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	send, _, closeSend := processOrders(t, client) // three orders per batch
+
+	// o2 is cancelled while waiting in the batch for o3.
+	send("o1", "o2")
+	for {
+		ord, err := client.GetOrder(ctx, &pb.OrderId{Id: "o2"})
+		if err != nil {
+			t.Fatalf("getOrder: %v", err)
+		}
+		if ord.Status == pb.OrderStatus_ORDER_STATUS_PROCESSING {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := client.CancelOrder(ctx, &pb.OrderId{Id: "o2"}); err != nil {
+		t.Fatalf("cancelOrder: %v", err)
+	}
+	send("o3")
+	checkResults(t, closeSend(), "rejected o2: FailedPrecondition", sanJose+": o1,o3")
+
+	ord, err := client.GetOrder(ctx, &pb.OrderId{Id: "o1"})
+	if err != nil {
+		t.Fatalf("getOrder: %v", err)
+	}
+	shipment, err := client.GetShipment(ctx, &pb.ShipmentId{Id: ord.ShipmentId})
+	if err != nil {
+		t.Fatalf("getShipment: %v", err)
+	}
+	if total := money.Format(shipment.Total); total != money.Format(money.New("USD", 800, 0)) {
+		t.Errorf("shipment total is %v, want the 800 USD of o1 and o3", total)
+	}
+	if ord, err := client.GetOrder(ctx, &pb.OrderId{Id: "o2"}); err != nil || ord.ShipmentId != "" {
+		t.Errorf("cancelled order is in shipment %q, %v, want none", ord.GetShipmentId(), err)
+	}
+}
+
+func TestUnlinkLostShipments(t *testing.T) {
+	orders, shipments := newMemOrderStore(), newMemShipmentStore()
+	if err := shipments.Put(&pb.CombinedShipment{Id: "kept"}); err != nil {
+		t.Fatal(err)
+	}
+	for id, shipment := range map[string]string{"o1": "lost", "o2": "kept", "o3": ""} {
+		ord := newOrder(id, sanJose)
+		ord.ShipmentId = shipment
+		if err := orders.Put(ord); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := unlinkLostShipments(orders, shipments)
+	if n != 1 || err != nil {
+		t.Fatalf("unlinkLostShipments = %v, %v, want 1 order", n, err)
+	}
+	for id, want := range map[string]string{"o1": "", "o2": "kept", "o3": ""} {
+		if ord, _ := orders.Get(id); ord.ShipmentId != want {
+			t.Errorf("order %v is in shipment %q, want %q", id, ord.ShipmentId, want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	pb "ecommerce/order/proto"
+	"ecommerce/rpcerr"
+	"ecommerce/validation"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
+	"log"
+)
+
+const (
+	// updateModeKey is the request metadata key selecting how UpdateOrders
+	// applies a stream. By default every order is applied on its own as it
+	// arrives; with updateModeAtomic they are applied together at the end of
+	// the stream, and only if none of them is rejected.
+	updateModeKey    = "update-mode"
+	updateModeAtomic = "atomic"
+)
+
+var errRolledBack = errors.New("atomic update rolled back")
+
+// applyOrderUpdate returns the order to store when update is written over
+// current, which is nil for a new order. New orders start PENDING and hold
+// the stock reservation reservationId made for their line items by
+// prepareNewOrder. The status only changes through transitions, and the
+// line items of an existing order cannot change, so that its reservation and
+// shipment, kept from creation and ProcessOrders, still match it. Nor does
+// its price: the line items, their unit prices, the item names and the total
+// priced when the order was placed are kept, whatever the catalog says now.
+func applyOrderUpdate(current, update *pb.Order, reservationId string) (*pb.Order, error) {
+	if update.Id == "" {
+		return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, "id", "Order ID is required")
+	}
+	if err := checkVersion(update.Id, update.Version, current); err != nil {
+		return nil, err
+	}
+	resolveAddress(update)
+	if current == nil {
+		if len(update.LineItems) > 0 && reservationId == "" {
+			// The order existed when prepareNewOrder looked it up.
+			return nil, rpcerr.Status(codes.Aborted, rpcerr.ReasonVersionMismatch, nil,
+				fmt.Sprintf("Order %v was deleted while being updated, retry", update.Id)).Err()
+		}
+		update.ReservationId = reservationId
+		update.ShipmentId = ""
+		if err := startOrder(update); err != nil {
+			return nil, err
+		}
+		update.Version = 1
+		return update, nil
+	}
+	if !isEditable(current.Status) {
+		return nil, rpcerr.FailedPrecondition(rpcerr.ReasonNotEditable, orderResource, update.Id,
+			map[string]string{"status": current.Status.String()},
+			"Order %v is %v and can no longer be updated", update.Id, current.Status)
+	}
+	if update.Status != pb.OrderStatus_ORDER_STATUS_UNSPECIFIED && update.Status != current.Status {
+		return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, "status", "Order %v status must be changed with transitionOrder", update.Id)
+	}
+	if !sameLineItems(current.LineItems, update.LineItems) {
+		return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, "line_items",
+			"Order %v line items cannot change once placed, cancel it and place a new order", update.Id)
+	}
+	if len(current.LineItems) > 0 {
+		update.LineItems = current.LineItems
+		update.Items = current.Items
+		update.Price = current.Price
+	}
+	update.Status = current.Status
+	update.ReservationId = current.ReservationId
+	update.ShipmentId = current.ShipmentId
+	update.Version = current.Version + 1
+	return update, nil
+}
+
+// sameLineItems reports whether a and b order the same quantities of the
+// same products, in the same order.
+func sameLineItems(a, b []*pb.LineItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ProductId != b[i].ProductId || a[i].Quantity != b[i].Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// prepareNewOrder prices ord from the catalog and reserves the stock of its
+// line items if it is a new order, returning the id of the reservation, or
+// "" if none was needed. The caller passes it to applyOrderUpdate and
+// releases it with releaseUnused unless the written order holds it.
+func (s *Server) prepareNewOrder(ctx context.Context, ord *pb.Order) (string, error) {
+	if len(ord.LineItems) == 0 {
+		return "", nil
+	}
+	if _, exists := s.orders.Get(ord.Id); exists {
+		return "", nil
+	}
+	if err := priceOrder(ctx, s.catalog, ord); err != nil {
+		return "", err
+	}
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return "", rpcerr.Internal("Generating reservation ID: %v", err)
+	}
+	if err := s.stock.Reserve(ctx, id.String(), ord.LineItems); err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// releaseUnused releases reservation reservationId of order id unless
+// written, the order as stored, holds it.
+func (s *Server) releaseUnused(tag0, id, reservationId string, written *pb.Order) {
+	if reservationId == "" || written.GetReservationId() == reservationId {
+		return
+	}
+	s.releaseStock(tag0, &pb.Order{Id: id, ReservationId: reservationId})
+}
+
+func writtenResult(current, written *pb.Order) *pb.UpdateResult {
+	outcome := pb.UpdateOutcome_UPDATE_OUTCOME_UPDATED
+	if current == nil {
+		outcome = pb.UpdateOutcome_UPDATE_OUTCOME_CREATED
+	}
+	return &pb.UpdateResult{Id: written.Id, Outcome: outcome, Version: written.Version}
+}
+
+func rejectedResult(id string, reason error) *pb.UpdateResult {
+	return &pb.UpdateResult{
+		Id:      id,
+		Outcome: pb.UpdateOutcome_UPDATE_OUTCOME_REJECTED,
+		Status:  status.Convert(reason).Proto(),
+	}
+}
+
+// updateOrder writes a single streamed order and reports the outcome.
+func (s *Server) updateOrder(ctx context.Context, tag0 string, order *pb.Order) *pb.UpdateResult {
+	reservationId, err := s.prepareNewOrder(ctx, order)
+	if err != nil {
+		return rejectedResult(order.Id, err)
+	}
+	var result *pb.UpdateResult
+	written, err := s.orders.Update(order.Id, func(current *pb.Order) (*pb.Order, error) {
+		written, err := applyOrderUpdate(current, order, reservationId)
+		if err != nil {
+			return nil, err
+		}
+		result = writtenResult(current, written)
+		return written, nil
+	})
+	s.releaseUnused(tag0, order.Id, reservationId, written)
+	if err != nil {
+		return rejectedResult(order.Id, storeError(err, order.Id))
+	}
+	s.index.refresh(s.orders, order.Id)
+	return result
+}
+
+// streamedOrder is an order received by UpdateOrders, rejected holding why
+// the order cannot be applied if the validation interceptor or preparing it
+// as a new order failed.
+type streamedOrder struct {
+	order    *pb.Order
+	rejected error
+}
+
+// updateOrdersAtomically writes all orders in one store transaction, unless
+// any of them is rejected, in which case nothing is written.
+func (s *Server) updateOrdersAtomically(ctx context.Context, tag0 string, orders []streamedOrder) (results []*pb.UpdateResult, committed bool) {
+	ids := make([]string, len(orders))
+	for i, o := range orders {
+		ids[i] = o.order.Id
+	}
+
+	// The first occurrence of each new order is priced and its stock
+	// reserved, later ones update it. Unused reservations are released in
+	// the end.
+	reservations := make(map[string]string)
+	for i, o := range orders {
+		if _, seen := reservations[o.order.Id]; seen || o.rejected != nil {
+			continue
+		}
+		id, err := s.prepareNewOrder(ctx, o.order)
+		if err != nil {
+			orders[i].rejected = err
+			continue
+		}
+		reservations[o.order.Id] = id
+	}
+	stored := make(map[string]*pb.Order)
+	defer func() {
+		for id, reservationId := range reservations {
+			s.releaseUnused(tag0, id, reservationId, stored[id])
+		}
+	}()
+
+	err := s.orders.UpdateAll(ids, func(current map[string]*pb.Order) ([]*pb.Order, error) {
+		results = make([]*pb.UpdateResult, 0, len(orders))
+		// An order may be streamed more than once, later writes see earlier ones.
+		working := make(map[string]*pb.Order, len(current))
+		for id, ord := range current {
+			working[id] = ord
+		}
+
+		var written []*pb.Order
+		rollback := false
+		for _, o := range orders {
+			order := o.order
+			if o.rejected != nil {
+				results = append(results, rejectedResult(order.Id, o.rejected))
+				rollback = true
+				continue
+			}
+			next, err := applyOrderUpdate(working[order.Id], order, reservations[order.Id])
+			if err != nil {
+				results = append(results, rejectedResult(order.Id, err))
+				rollback = true
+				continue
+			}
+			results = append(results, writtenResult(working[order.Id], next))
+			working[order.Id] = next
+			written = append(written, next)
+		}
+		if rollback {
+			return nil, errRolledBack
+		}
+		return written, nil
+	})
+
+	switch {
+	case err == nil:
+		for _, id := range ids {
+			stored[id], _ = s.orders.Get(id)
+		}
+		for _, id := range ids {
+			s.index.refresh(s.orders, id)
+		}
+		return results, true
+	case errors.Is(err, errRolledBack):
+		for _, result := range results {
+			if result.Outcome != pb.UpdateOutcome_UPDATE_OUTCOME_REJECTED {
+				result.Outcome = pb.UpdateOutcome_UPDATE_OUTCOME_NOT_APPLIED
+				result.Version = 0
+			}
+		}
+		return results, false
+	default:
+		results = make([]*pb.UpdateResult, len(orders))
+		for i, o := range orders {
+			results[i] = rejectedResult(o.order.Id, storeError(err, o.order.Id))
+		}
+		return results, false
+	}
+}
+
+// UpdateOrders Client-side Streaming RPC
+func (s *Server) UpdateOrders(stream pb.OrderManagement_UpdateOrdersServer) error {
+	tag0 := tag + " [CS-UO]"
+
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	atomic := len(md.Get(updateModeKey)) > 0 && md.Get(updateModeKey)[0] == updateModeAtomic
+
+	resp := &pb.UpdateOrdersRequest{Committed: true}
+	var pending []streamedOrder
+	for {
+		order, err := stream.Recv()
+		if err == io.EOF {
+			// Finished reading the order stream.
+			if atomic {
+				resp.Results, resp.Committed = s.updateOrdersAtomically(stream.Context(), tag0, pending)
+			}
+			for _, result := range resp.Results {
+				switch result.Outcome {
+				case pb.UpdateOutcome_UPDATE_OUTCOME_CREATED, pb.UpdateOutcome_UPDATE_OUTCOME_UPDATED:
+					log.Printf("%v Order ID : %s - Updated\n", tag0, result.Id)
+					resp.Id = append(resp.Id, result.Id)
+				default:
+					log.Printf("%v Order ID : %s - %v %v\n", tag0, result.Id, result.Outcome, result.Status.GetMessage())
+				}
+			}
+			return stream.SendAndClose(resp)
+		}
+
+		var rejected error
+		var invalid *validation.Error
+		if errors.As(err, &invalid) {
+			// Rejected by the validation interceptor, move on to the next order.
+			order = invalid.Message.(*pb.Order)
+			rejected = invalid
+		} else if err != nil {
+			return err
+		}
+		if atomic {
+			pending = append(pending, streamedOrder{order: order, rejected: rejected})
+			continue
+		}
+		if rejected != nil {
+			resp.Results = append(resp.Results, rejectedResult(order.Id, rejected))
+			continue
+		}
+		resp.Results = append(resp.Results, s.updateOrder(stream.Context(), tag0, order))
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	pb "ecommerce/order/proto"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"testing"
+)
+
+// updateOrders streams orders to updateOrders, atomically if atomic is set,
+// and returns its results, rendered as "id OUTCOME" or "id REJECTED Code".
+func updateOrders(t *testing.T, client pb.OrderManagementClient, atomic bool, orders ...*pb.Order) []string {
+	t.Helper()
+	ctx := context.Background()
+	if atomic {
+		ctx = metadata.AppendToOutgoingContext(ctx, updateModeKey, updateModeAtomic)
+	}
+	stream, err := client.UpdateOrders(ctx)
+	if err != nil {
+		t.Fatalf("updateOrders: %v", err)
+	}
+	for _, ord := range orders {
+		if err := stream.Send(ord); err != nil {
+			t.Fatalf("send %v: %v", ord.Id, err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("updateOrders: %v", err)
+	}
+	results := make([]string, len(resp.Results))
+	for i, res := range resp.Results {
+		results[i] = fmt.Sprintf("%v %v", res.Id, res.Outcome)
+		if res.Outcome == pb.UpdateOutcome_UPDATE_OUTCOME_REJECTED {
+			results[i] += fmt.Sprintf(" %v", codes.Code(res.Status.Code))
+		}
+	}
+	return results
+}
+
+func TestUpdateOrdersCreatesPendingOrders(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	shipped := newOrder("o2", sanJose)
+	shipped.Status = pb.OrderStatus_ORDER_STATUS_SHIPPED
+	checkResults(t, updateOrders(t, client, false, newOrder("o1", sanJose), shipped),
+		"o1 UPDATE_OUTCOME_CREATED",
+		"o2 UPDATE_OUTCOME_REJECTED InvalidArgument",
+	)
+	if ord, err := client.GetOrder(context.Background(), &pb.OrderId{Id: "o1"}); err != nil || ord.Status != pb.OrderStatus_ORDER_STATUS_PENDING {
+		t.Errorf("order o1 is %v, %v, want PENDING", ord.GetStatus(), err)
+	}
+}
+
+// lineItemOrder returns an order of quantity units of product p1.
+func lineItemOrder(id string, quantity int32) *pb.Order {
+	return &pb.Order{Id: id, Destination: sanJose, LineItems: []*pb.LineItem{{ProductId: "p1", Quantity: quantity}}}
+}
+
+func TestUpdateOrdersReservesStock(t *testing.T) {
+	stock := newFakeStock()
+	client := startServer(t, newMemOrderStore(), testCatalog, stock)
+	ctx := context.Background()
+	reservation := func(id string) string {
+		t.Helper()
+		ord, err := client.GetOrder(ctx, &pb.OrderId{Id: id})
+		if err != nil {
+			t.Fatalf("getOrder %v: %v", id, err)
+		}
+		return ord.ReservationId
+	}
+
+	checkResults(t, updateOrders(t, client, false, lineItemOrder("o1", 1)), "o1 UPDATE_OUTCOME_CREATED")
+	id := reservation("o1")
+	if id == "" || stock.state(id) != "reserved" {
+		t.Fatalf("order created by updateOrders holds reservation %q %v, want a reserved one", id, stock.state(id))
+	}
+
+	// An update keeps the reservation, and may not change the line items it
+	// was made for.
+	update := lineItemOrder("o1", 1)
+	update.Description = "gift"
+	checkResults(t, updateOrders(t, client, false, update, lineItemOrder("o1", 2)),
+		"o1 UPDATE_OUTCOME_UPDATED",
+		"o1 UPDATE_OUTCOME_REJECTED InvalidArgument",
+	)
+	if got := reservation("o1"); got != id || stock.state(id) != "reserved" {
+		t.Errorf("updated order holds reservation %q %v, want %q reserved", got, stock.state(got), id)
+	}
+
+	// The reservations of a rolled back atomic stream are released.
+	checkResults(t, updateOrders(t, client, true, lineItemOrder("o2", 1), lineItemOrder("o1", 3)),
+		"o2 UPDATE_OUTCOME_NOT_APPLIED",
+		"o1 UPDATE_OUTCOME_REJECTED InvalidArgument",
+	)
+	stock.mu.Lock()
+	defer stock.mu.Unlock()
+	if len(stock.reservations) != 2 {
+		t.Fatalf("%d reservations, want 2", len(stock.reservations))
+	}
+	for reservationId, state := range stock.reservations {
+		if reservationId != id && state != "released" {
+			t.Errorf("reservation %v of the rolled back stream is %v, want released", reservationId, state)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	pb "ecommerce/order/proto"
+	"ecommerce/validation"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// newOrderValidator returns the input rules of the OrderManagement service.
+// Order ids are not required here: addOrder generates missing ones, and
+// updateOrders rejects orders without id itself.
+func newOrderValidator() *validation.Registry {
+	v := validation.NewRegistry()
+	v.Register(&pb.Order{},
+		// Orders with line items get their items and price from the catalog.
+		validation.When(withoutLineItems,
+			validation.MinItems("items", 1),
+			validation.Required("price"),
+		),
+		validation.Money("price"),
+		validation.NonNegative("price"),
+		validation.Each("line_items",
+			validation.Required("product_id"),
+			validation.Positive("quantity"),
+		),
+		// Older clients only send the destination.
+		validation.When(withoutAddress, validation.Required("destination")),
+		validation.When(withAddress, validation.Required("address.city")),
+		validation.MaxLen("description", 1024),
+	)
+	v.Register(&pb.OrderId{}, validation.Required("id"))
+	v.Register(&pb.TransitionRequest{}, validation.Required("id"), validation.Required("status"))
+	v.Register(&pb.SearchRequest{}, validation.NonNegative("limit"))
+	v.Register(&pb.ShipmentId{}, validation.Required("id"))
+	v.Register(&pb.ListShipmentsRequest{}, validation.NonNegative("page_size"))
+	v.Register(&pb.WatchOrdersRequest{}, validation.NonNegative("after_revision"))
+	return v
+}
+
+func withoutLineItems(m protoreflect.Message) bool {
+	return len(m.Interface().(*pb.Order).LineItems) == 0
+}
+
+func withAddress(m protoreflect.Message) bool {
+	return m.Interface().(*pb.Order).Address != nil
+}
+
+func withoutAddress(m protoreflect.Message) bool {
+	return !withAddress(m)
+}
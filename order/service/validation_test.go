@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"ecommerce/money"
+	pb "ecommerce/order/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"testing"
+)
+
+func TestInvalidRequests(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		call   func() error
+		fields string // violated fields, comma separated
+	}{
+		{"addOrder without items or destination", func() error {
+			_, err := client.AddOrder(ctx, &pb.Order{Price: money.New("USD", 400, 0)})
+			return err
+		}, "items,destination"},
+		{"addOrder with negative price", func() error {
+			_, err := client.AddOrder(ctx, &pb.Order{Items: []string{"Apple Watch S4"}, Destination: sanJose, Price: money.New("USD", -1, 0)})
+			return err
+		}, "price"},
+		{"addOrder without price", func() error {
+			ord := newOrder("", sanJose)
+			ord.Price = nil
+			_, err := client.AddOrder(ctx, ord)
+			return err
+		}, "price"},
+		{"addOrder with invalid currency", func() error {
+			ord := newOrder("", sanJose)
+			ord.Price = money.New("usd", 400, 0)
+			_, err := client.AddOrder(ctx, ord)
+			return err
+		}, "price"},
+		{"addOrder with long description", func() error {
+			ord := newOrder("", sanJose)
+			ord.Description = strings.Repeat("x", 1025)
+			_, err := client.AddOrder(ctx, ord)
+			return err
+		}, "description"},
+		{"getOrder without id", func() error {
+			_, err := client.GetOrder(ctx, &pb.OrderId{})
+			return err
+		}, "id"},
+		{"transitionOrder without status", func() error {
+			_, err := client.TransitionOrder(ctx, &pb.TransitionRequest{Id: "o1"})
+			return err
+		}, "status"},
+		{"searchOrders with negative limit", func() error {
+			stream, err := client.SearchOrders(ctx, &pb.SearchRequest{Limit: -1})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			return err
+		}, "limit"},
+	}
+	for _, test := range tests {
+		err := test.call()
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v = %v, want InvalidArgument", test.name, err)
+			continue
+		}
+		var fields []string
+		for _, d := range status.Convert(err).Details() {
+			if br, ok := d.(*errdetails.BadRequest); ok {
+				for _, v := range br.FieldViolations {
+					fields = append(fields, v.Field)
+				}
+			}
+		}
+		if got := strings.Join(fields, ","); got != test.fields {
+			t.Errorf("%v: violated fields %q, want %q", test.name, got, test.fields)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	pb "ecommerce/order/proto"
+	"ecommerce/rpcerr"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"sync"
+)
+
+// orderFeed numbers the changes made to an OrderStore and keeps the latest
+// of them in a ring buffer, so that WatchOrders streams can replay the
+// changes they missed, e.g. while reconnecting.
+type orderFeed struct {
+	mu       sync.Mutex
+	events   []*pb.OrderEvent // event of revision r at r % len(events)
+	revision int64            // latest revision, 0 before the first change
+	watchers map[chan struct{}]bool
+}
+
+func newOrderFeed(history int) *orderFeed {
+	if history < 1 {
+		history = 1
+	}
+	return &orderFeed{events: make([]*pb.OrderEvent, history), watchers: make(map[chan struct{}]bool)}
+}
+
+// watch returns orders with every write recorded in the feed. Writes made
+// to orders directly are not.
+func (f *orderFeed) watch(orders OrderStore) OrderStore {
+	return &watchedOrderStore{OrderStore: orders, feed: f}
+}
+
+// publish records the change of an order from before to after, either of
+// which is nil for created or deleted orders, and wakes up the watchers.
+func (f *orderFeed) publish(before, after *pb.Order) {
+	event := &pb.OrderEvent{Before: before, After: after}
+	switch {
+	case before == nil:
+		event.Type = pb.OrderEventType_ORDER_EVENT_TYPE_CREATED
+	case after == nil:
+		event.Type = pb.OrderEventType_ORDER_EVENT_TYPE_DELETED
+	case after.Status == pb.OrderStatus_ORDER_STATUS_CANCELLED && before.Status != after.Status:
+		event.Type = pb.OrderEventType_ORDER_EVENT_TYPE_CANCELLED
+	default:
+		event.Type = pb.OrderEventType_ORDER_EVENT_TYPE_UPDATED
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.revision++
+	event.Revision = f.revision
+	f.events[f.revision%int64(len(f.events))] = event
+	for ch := range f.watchers {
+		// A pending notification already covers this change.
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// subscribe returns the latest revision, a channel that receives a value
+// after further changes, and a function to unsubscribe.
+func (f *orderFeed) subscribe() (int64, <-chan struct{}, func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	f.watchers[ch] = true
+	return f.revision, ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.watchers, ch)
+	}
+}
+
+// since returns the events after revision after, oldest first. It fails
+// with OutOfRange if some of them are no longer in the ring buffer, or if
+// after is ahead of the feed, as happens when resuming across a restart.
+func (f *orderFeed) since(after int64) ([]*pb.OrderEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	oldest := f.revision - int64(len(f.events)) + 1
+	if oldest < 1 {
+		oldest = 1
+	}
+	if after < oldest-1 || after > f.revision {
+		metadata := map[string]string{
+			"oldest_revision": fmt.Sprint(oldest),
+			"latest_revision": fmt.Sprint(f.revision),
+		}
+		return nil, rpcerr.Status(codes.OutOfRange, rpcerr.ReasonRevisionOutOfRange, metadata,
+			fmt.Sprintf("Cannot stream the changes after revision %v, the history holds revisions %v to %v", after, oldest, f.revision)).Err()
+	}
+	events := make([]*pb.OrderEvent, 0, f.revision-after)
+	for r := after + 1; r <= f.revision; r++ {
+		events = append(events, f.events[r%int64(len(f.events))])
+	}
+	return events, nil
+}
+
+// watchedOrderStore is the OrderStore decorator publishing every successful
+// write to its feed. Writes are serialized so that revisions follow the
+// order in which they were applied.
+type watchedOrderStore struct {
+	OrderStore
+	mu   sync.Mutex
+	feed *orderFeed
+}
+
+func (w *watchedOrderStore) Put(order *pb.Order) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	before, _ := w.OrderStore.Get(order.Id)
+	if err := w.OrderStore.Put(order); err != nil {
+		return err
+	}
+	w.feed.publish(before, order)
+	return nil
+}
+
+func (w *watchedOrderStore) Update(id string, fn func(current *pb.Order) (*pb.Order, error)) (*pb.Order, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var before *pb.Order
+	updated, err := w.OrderStore.Update(id, func(current *pb.Order) (*pb.Order, error) {
+		before = current
+		return fn(current)
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.feed.publish(before, updated)
+	return updated, nil
+}
+
+func (w *watchedOrderStore) UpdateAll(ids []string, fn func(current map[string]*pb.Order) ([]*pb.Order, error)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var before map[string]*pb.Order
+	var updated []*pb.Order
+	err := w.OrderStore.UpdateAll(ids, func(current map[string]*pb.Order) ([]*pb.Order, error) {
+		var err error
+		before = current
+		updated, err = fn(current)
+		return updated, err
+	})
+	if err != nil {
+		return err
+	}
+	for _, ord := range updated {
+		w.feed.publish(before[ord.Id], ord)
+	}
+	return nil
+}
+
+func (w *watchedOrderStore) Delete(id string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	before, exists := w.OrderStore.Get(id)
+	if err := w.OrderStore.Delete(id); err != nil {
+		return err
+	}
+	if exists {
+		w.feed.publish(before, nil)
+	}
+	return nil
+}
+
+// WatchOrders Server Streaming RPC
+func (s *Server) WatchOrders(req *pb.WatchOrdersRequest, stream pb.OrderManagement_WatchOrdersServer) error {
+	tag0 := tag + " [W]"
+
+	// Subscribe before reading the history, so that no change goes unnoticed.
+	latest, changed, stop := s.feed.subscribe()
+	defer stop()
+	after := req.AfterRevision
+	if after == 0 {
+		after = latest
+	}
+
+	ids := make(map[string]bool, len(req.Ids))
+	for _, id := range req.Ids {
+		ids[id] = true
+	}
+	for {
+		events, err := s.feed.since(after)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			after = event.Revision
+			if len(ids) > 0 && !ids[eventOrderId(event)] {
+				continue
+			}
+			log.Printf("%v [Send] %v %v %v\n", tag0, event.Revision, event.Type, eventOrderId(event))
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+func eventOrderId(event *pb.OrderEvent) string {
+	if event.After != nil {
+		return event.After.Id
+	}
+	return event.Before.GetId()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	pb "ecommerce/order/proto"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+// watchOrders opens a watchOrders stream and returns a function receiving
+// its next n events, rendered as "revision type id".
+func watchOrders(t *testing.T, client pb.OrderManagementClient, req *pb.WatchOrdersRequest) func(n int) []string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	stream, err := client.WatchOrders(ctx, req)
+	if err != nil {
+		t.Fatalf("watchOrders: %v", err)
+	}
+	return func(n int) []string {
+		t.Helper()
+		var events []string
+		for len(events) < n {
+			event, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("recv after %q: %v", events, err)
+			}
+			events = append(events, fmt.Sprintf("%d %v %v", event.Revision, event.Type, eventOrderId(event)))
+		}
+		return events
+	}
+}
+
+func TestWatchOrders(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	placeOrders(t, client, 400, "o1", sanJose)
+
+	// Revision 1 is the creation of o1, already made.
+	recv := watchOrders(t, client, &pb.WatchOrdersRequest{AfterRevision: 1})
+	placeOrders(t, client, 400, "o2", sanJose)
+	checkResults(t, recv(1), "2 ORDER_EVENT_TYPE_CREATED o2")
+	if _, err := client.CancelOrder(context.Background(), &pb.OrderId{Id: "o1"}); err != nil {
+		t.Fatalf("cancelOrder: %v", err)
+	}
+	if _, err := client.TransitionOrder(context.Background(), &pb.TransitionRequest{Id: "o2", Status: pb.OrderStatus_ORDER_STATUS_PROCESSING}); err != nil {
+		t.Fatalf("transitionOrder: %v", err)
+	}
+	checkResults(t, recv(2), "3 ORDER_EVENT_TYPE_CANCELLED o1", "4 ORDER_EVENT_TYPE_UPDATED o2")
+
+	// A resumed watch replays the changes after its revision, of its orders only.
+	resumed := watchOrders(t, client, &pb.WatchOrdersRequest{AfterRevision: 1, Ids: []string{"o2"}})
+	checkResults(t, resumed(2), "2 ORDER_EVENT_TYPE_CREATED o2", "4 ORDER_EVENT_TYPE_UPDATED o2")
+}
+
+func TestWatchOrdersOutOfRange(t *testing.T) {
+	client := startServer(t, newMemOrderStore(), testCatalog, newFakeStock())
+	placeOrders(t, client, 400, "o1", sanJose)
+	stream, err := client.WatchOrders(context.Background(), &pb.WatchOrdersRequest{AfterRevision: 2})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.OutOfRange {
+		t.Errorf("watchOrders ahead of the feed = %v, want OutOfRange", err)
+	}
+}
+
+func TestOrderFeedHistory(t *testing.T) {
+	feed := newOrderFeed(2)
+	for i := 0; i < 3; i++ {
+		feed.publish(nil, newOrder(fmt.Sprint(i), sanJose))
+	}
+	if events, err := feed.since(1); err != nil || len(events) != 2 || events[0].Revision != 2 {
+		t.Errorf("since(1) = %v, %v, want revisions 2 and 3", events, err)
+	}
+	if _, err := feed.since(0); status.Code(err) != codes.OutOfRange {
+		t.Errorf("since(0) = %v, want OutOfRange once revision 1 is gone", err)
+	}
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 
+	"ecommerce/auth"
+	"ecommerce/money"
 	pb "ecommerce/product/proto"
+	"ecommerce/rpcerr"
+	"ecommerce/tlsconfig"
 )
 
 const (
@@ -16,8 +21,23 @@ const (
 	tag  = "[Client]"
 )
 
+var (
+	clientTLS  = tlsconfig.ClientFlags("")
+	clientAuth = auth.ClientFlags("")
+)
+
 func main() {
-	con, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	flag.Parse()
+	creds, err := clientTLS.Credentials()
+	if err != nil {
+		log.Fatalf("%vFailed to load TLS credentials: %v\n", tag, err)
+	}
+	authOpts, err := clientAuth.DialOptions(clientTLS.Enabled())
+	if err != nil {
+		log.Fatalf("%vFailed to load the token: %v\n", tag, err)
+	}
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, authOpts...)
+	con, err := grpc.Dial(addr, opts...)
 
 	if err != nil {
 		log.Fatalf("%vFailed to connect: %v\n", tag, err)
@@ -38,13 +58,13 @@ func main() {
 
 	product, err := c.GetProduct(ctx, &pb.ProductID{Value: "9d6800bb-4321-44d1-a102-bfb4b301793a"})
 	if err != nil {
-		log.Printf("%v [R] [Error]: %v\n\n", tag, err)
+		log.Printf("%v [R] [Error]: %v\n\n", tag, rpcerr.Describe(err))
 	}
 	log.Printf("%v [R] [Success] %v \n", tag, product)
 
 	name := "Apple iPhone 14 Plus"
 	desc := "Big and bigger."
-	price := float32(899.0)
+	price := money.MustParse("USD", "899.00")
 
 	r, err := c.AddProduct(ctx, &pb.Product{Name: name, Description: desc, Price: price})
 
@@ -59,4 +79,61 @@ func main() {
 	}
 	log.Printf("%v [R] [Success] %v \n", tag, product)
 
+	_, err = c.AddProduct(ctx, &pb.Product{Description: "No name, no price"})
+	log.Printf("%v [C] [Invalid] %v \n", tag, rpcerr.Describe(err))
+
+	for _, p := range []*pb.Product{
+		{Name: "Google Pixel 7", Description: "Made by Google.", Price: money.MustParse("USD", "599.0")},
+		{Name: "Apple Watch Ultra", Description: "Adventure awaits.", Price: money.MustParse("USD", "799.0")},
+		{Name: "Amazon Echo Dot", Description: "Smart speaker.", Price: money.MustParse("USD", "49.99")},
+	} {
+		if _, err := c.AddProduct(ctx, p); err != nil {
+			log.Fatalf("%v [Error] Fail to add product: %v\n\n", tag, err)
+		}
+	}
+
+	read := product
+	product, err = c.UpdateProduct(ctx, &pb.UpdateProductRequest{
+		Product:    &pb.Product{Id: r.Value, Price: money.MustParse("USD", "849.0"), Version: read.Version},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"price"}},
+	})
+	if err != nil {
+		log.Fatalf("%v [Error] Fail to update product: %v\n\n", tag, err)
+	}
+	log.Printf("%v [U] [Success] %v \n", tag, product)
+
+	// Still based on the version read before the update above, so it is aborted.
+	_, err = c.UpdateProduct(ctx, &pb.UpdateProductRequest{
+		Product:    &pb.Product{Id: r.Value, Price: money.MustParse("USD", "829.0"), Version: read.Version},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"price"}},
+	})
+	log.Printf("%v [U] [Conflict] %v \n", tag, rpcerr.Describe(err))
+
+	listProducts(ctx, c, pb.ProductOrder_PRODUCT_ORDER_PRICE, 2)
+
+	if _, err := c.DeleteProduct(ctx, &pb.ProductID{Value: r.Value}); err != nil {
+		log.Fatalf("%v [Error] Fail to delete product: %v\n\n", tag, err)
+	}
+	log.Printf("%v [D] [Success] %v \n", tag, r.Value)
+
+	_, err = c.GetProduct(ctx, &pb.ProductID{Value: r.Value})
+	log.Printf("%v [R] [Deleted] %v \n", tag, rpcerr.Describe(err))
+}
+
+// listProducts walks every page of the catalog.
+func listProducts(ctx context.Context, c pb.ProductInfoClient, orderBy pb.ProductOrder, pageSize int32) {
+	req := &pb.ListProductsRequest{PageSize: pageSize, OrderBy: orderBy}
+	for page := 1; ; page++ {
+		resp, err := c.ListProducts(ctx, req)
+		if err != nil {
+			log.Fatalf("%v [Error] Fail to list products: %v\n\n", tag, err)
+		}
+		for _, p := range resp.Products {
+			log.Printf("%v [L] [Page %v] %v \n", tag, page, p)
+		}
+		if resp.NextPageToken == "" {
+			return
+		}
+		req.PageToken = resp.NextPageToken
+	}
 }
@@ -0,0 +1,16 @@
+package server
+
+import (
+	"ecommerce/auth"
+	pb "ecommerce/product/proto"
+)
+
+// Policy lets any authenticated caller browse the catalog, and only
+// catalog admins change it.
+var Policy = auth.NewPolicy(pb.ProductInfo_ServiceDesc, map[string][]string{
+	"addProduct":    {auth.RoleCatalogAdmin},
+	"getProduct":    nil,
+	"updateProduct": {auth.RoleCatalogAdmin},
+	"deleteProduct": {auth.RoleCatalogAdmin},
+	"listProducts":  nil,
+})
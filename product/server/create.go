@@ -0,0 +1,30 @@
+package server
+
+import (
+	"context"
+	pb "ecommerce/product/proto"
+	"ecommerce/rpcerr"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) AddProduct(ctx context.Context, in *pb.Product) (*pb.ProductID, error) {
+	out, err := uuid.NewUUID()
+
+	if err != nil {
+		return nil, rpcerr.Internal("[Error] Generating Product ID: %v", err)
+	}
+
+	in.Id = out.String()
+	in.Version = 1
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.saveProduct(in); err != nil {
+		return nil, rpcerr.Internal("[Error] Saving Product %v: %v", in.Id, err)
+	}
+	s.productMap[in.Id] = in
+
+	return &pb.ProductID{Value: in.Id}, status.New(codes.OK, "").Err()
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"context"
+	pb "ecommerce/product/proto"
+	"ecommerce/rpcerr"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *Server) DeleteProduct(ctx context.Context, in *pb.ProductID) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.productMap[in.Value]; !exists {
+		return nil, rpcerr.NotFound(productResource, in.Value)
+	}
+	if err := s.deleteProduct(in.Value); err != nil {
+		return nil, rpcerr.Internal("[Error] Deleting Product %v: %v", in.Value, err)
+	}
+	delete(s.productMap, in.Value)
+
+	return &emptypb.Empty{}, status.New(codes.OK, "").Err()
+}
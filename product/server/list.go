@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"ecommerce/money"
+	pb "ecommerce/product/proto"
+	"ecommerce/rpcerr"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// pageToken is the decoded form of ListProductsResponse.next_page_token.
+// It holds the sort key of the last product returned, so the next page
+// resumes right after it even if products were added or removed meanwhile.
+type pageToken struct {
+	OrderBy    pb.ProductOrder `json:"o"`
+	Descending bool            `json:"d"`
+	Name       string          `json:"n,omitempty"`
+	Currency   string          `json:"c,omitempty"`
+	Units      int64           `json:"u,omitempty"`
+	Nanos      int32           `json:"a,omitempty"`
+	Id         string          `json:"i"`
+}
+
+func (t *pageToken) encode() string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodePageToken(s string) (*pageToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	t := &pageToken{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// compareProducts orders products by the requested key, breaking ties by id.
+func compareProducts(a, b *pb.Product, orderBy pb.ProductOrder) int {
+	switch orderBy {
+	case pb.ProductOrder_PRODUCT_ORDER_NAME:
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+	case pb.ProductOrder_PRODUCT_ORDER_PRICE:
+		if c := money.Compare(a.Price, b.Price); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.Id, b.Id)
+}
+
+func (s *Server) ListProducts(ctx context.Context, in *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	pageSize := int(in.PageSize)
+	switch {
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	var after *pb.Product
+	if in.PageToken != "" {
+		token, err := decodePageToken(in.PageToken)
+		if err != nil {
+			return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidPageToken, "page_token", "invalid page_token")
+		}
+		if token.OrderBy != in.OrderBy || token.Descending != in.Descending {
+			return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidPageToken, "page_token", "page_token was issued for a different ordering")
+		}
+		after = &pb.Product{Id: token.Id, Name: token.Name}
+		if token.Currency != "" {
+			after.Price = money.New(token.Currency, token.Units, token.Nanos)
+		}
+	}
+
+	cmp := func(a, b *pb.Product) int {
+		if in.Descending {
+			return compareProducts(b, a, in.OrderBy)
+		}
+		return compareProducts(a, b, in.OrderBy)
+	}
+
+	s.mu.RLock()
+	products := make([]*pb.Product, 0, len(s.productMap))
+	for _, p := range s.productMap {
+		if after == nil || cmp(p, after) > 0 {
+			products = append(products, p)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(products, func(i, j int) bool { return cmp(products[i], products[j]) < 0 })
+
+	resp := &pb.ListProductsResponse{}
+	if len(products) > pageSize {
+		products = products[:pageSize]
+		last := products[pageSize-1]
+		resp.NextPageToken = (&pageToken{
+			OrderBy:    in.OrderBy,
+			Descending: in.Descending,
+			Name:       last.Name,
+			Currency:   last.Price.GetCurrencyCode(),
+			Units:      last.Price.GetUnits(),
+			Nanos:      last.Price.GetNanos(),
+			Id:         last.Id,
+		}).encode()
+	}
+	resp.Products = products
+
+	return resp, status.New(codes.OK, "").Err()
+}
@@ -1,22 +1,22 @@
-package main
+package server
 
 import (
 	"context"
 	pb "ecommerce/product/proto"
-	"log"
+	"ecommerce/rpcerr"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-func (s *server) GetProduct(ctx context.Context, in *pb.ProductID) (*pb.Product, error) {
-	tag0 := tag + " [R]"
-	log.Printf("%v [Invoked]\n\n", tag0)
+func (s *Server) GetProduct(ctx context.Context, in *pb.ProductID) (*pb.Product, error) {
+	s.mu.RLock()
 	value, exists := s.productMap[in.Value]
+	s.mu.RUnlock()
 
 	if exists {
 		return value, status.New(codes.OK, "").Err()
 	}
 
-	return nil, status.Errorf(codes.NotFound, "Product does not exist", in.Value)
+	return nil, rpcerr.NotFound(productResource, in.Value)
 }
@@ -0,0 +1,38 @@
+// Package server implements the ProductInfo service, keeping the catalog in
+// memory and, once loaded from one, in a durable store. The product service
+// command serves it; tests start it in process.
+package server
+
+import (
+	"sync"
+
+	pb "ecommerce/product/proto"
+	"ecommerce/storage"
+)
+
+// productResource is the resource type reported in error details.
+var productResource = string((&pb.Product{}).ProtoReflect().Descriptor().FullName())
+
+// Server is the ProductInfo service. Its requests are expected to have been
+// checked against NewValidator, and its callers authorized by Policy.
+type Server struct {
+	pb.ProductInfoServer
+	// mu guards productMap; writers hold it across the durable write
+	// so the log and the map agree on the order of updates.
+	mu         sync.RWMutex
+	productMap map[string]*pb.Product
+	db         *storage.DB
+}
+
+// New returns a server with an empty catalog, kept in memory only until
+// Load is called.
+func New() *Server {
+	return &Server{productMap: make(map[string]*pb.Product)}
+}
+
+// Len returns the number of products in the catalog.
+func (s *Server) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.productMap)
+}
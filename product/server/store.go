@@ -0,0 +1,57 @@
+package server
+
+import (
+	"ecommerce/money"
+	pb "ecommerce/product/proto"
+	"ecommerce/storage"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Load fills productMap from the durable store.
+func (s *Server) Load(db *storage.DB) error {
+	var err error
+	db.Range(func(_ string, value []byte) bool {
+		p := &pb.Product{}
+		if err = proto.Unmarshal(value, p); err != nil {
+			return false
+		}
+		migrateProduct(p)
+		s.productMap[p.Id] = p
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	s.db = db
+	return nil
+}
+
+// migrateProduct converts the float price of a product stored before
+// prices were money amounts, which were always in USD.
+func migrateProduct(p *pb.Product) {
+	if p.Price == nil && p.LegacyPrice != 0 {
+		p.Price = money.FromFloat("USD", float64(p.LegacyPrice))
+		p.LegacyPrice = 0
+	}
+}
+
+// saveProduct fsyncs p to the durable store, if one is configured.
+func (s *Server) saveProduct(p *pb.Product) error {
+	if s.db == nil {
+		return nil
+	}
+	b, err := proto.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return s.db.Put(p.Id, b)
+}
+
+// deleteProduct removes id from the durable store, if one is configured.
+func (s *Server) deleteProduct(id string) error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Delete(id)
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	pb "ecommerce/product/proto"
+	"ecommerce/rpcerr"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func (s *Server) UpdateProduct(ctx context.Context, in *pb.UpdateProductRequest) (*pb.Product, error) {
+	paths := in.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = []string{"name", "description", "price"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.productMap[in.Product.Id]
+	if !exists {
+		return nil, rpcerr.NotFound(productResource, in.Product.Id)
+	}
+	if in.Product.Version != 0 && in.Product.Version != current.Version {
+		return nil, rpcerr.VersionMismatch(productResource, current.Id, current.Version, in.Product.Version)
+	}
+
+	// Stored products are shared with in-flight responses, update a copy.
+	updated := proto.Clone(current).(*pb.Product)
+	for _, path := range paths {
+		switch path {
+		case "name":
+			updated.Name = in.Product.Name
+		case "description":
+			updated.Description = in.Product.Description
+		case "price":
+			updated.Price = in.Product.Price
+		default:
+			return nil, rpcerr.InvalidArgument(rpcerr.ReasonInvalidArgument, "update_mask", "update_mask: unknown or immutable field %q", path)
+		}
+	}
+
+	updated.Version++
+
+	if err := s.saveProduct(updated); err != nil {
+		return nil, rpcerr.Internal("[Error] Saving Product %v: %v", updated.Id, err)
+	}
+	s.productMap[updated.Id] = updated
+
+	return updated, status.New(codes.OK, "").Err()
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	pb "ecommerce/product/proto"
+	"ecommerce/validation"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NewValidator returns the input rules of the ProductInfo service.
+func NewValidator() *validation.Registry {
+	v := validation.NewRegistry()
+	v.Register(&pb.Product{},
+		validation.Required("name"),
+		validation.MaxLen("name", 256),
+		validation.Required("price"),
+		validation.Money("price"),
+		validation.Positive("price"),
+	)
+	v.Register(&pb.ProductID{}, validation.Required("value"))
+	v.Register(&pb.UpdateProductRequest{},
+		validation.Required("product.id"),
+		validation.When(updates("name"), validation.Required("product.name")),
+		validation.When(updates("price"),
+			validation.Required("product.price"),
+			validation.Money("product.price"),
+			validation.Positive("product.price"),
+		),
+	)
+	v.Register(&pb.ListProductsRequest{}, validation.NonNegative("page_size"))
+	return v
+}
+
+// updates reports whether an UpdateProductRequest overwrites the given field.
+func updates(path string) func(m protoreflect.Message) bool {
+	return func(m protoreflect.Message) bool {
+		req := m.Interface().(*pb.UpdateProductRequest)
+		if len(req.GetUpdateMask().GetPaths()) == 0 {
+			return true
+		}
+		for _, p := range req.UpdateMask.Paths {
+			if p == path {
+				return true
+			}
+		}
+		return false
+	}
+}
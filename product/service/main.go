@@ -1,10 +1,17 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net"
+	"path/filepath"
 
+	"ecommerce/auth"
+	"ecommerce/middleware"
 	pb "ecommerce/product/proto"
+	"ecommerce/product/server"
+	"ecommerce/storage"
+	"ecommerce/tlsconfig"
 
 	"google.golang.org/grpc"
 )
@@ -14,12 +21,29 @@ const (
 	tag  = "[Server]"
 )
 
-type server struct {
-	pb.ProductInfoServer
-	productMap map[string]*pb.Product
-}
+var (
+	dataDir     = flag.String("data-dir", "", "directory for the durable product store (write-ahead log + snapshots)")
+	serverTLS   = tlsconfig.ServerFlags("")
+	serverAuth  = auth.ServerFlags()
+	metricsAddr = flag.String("metrics-addr", "", "serve call metrics at /debug/vars on this address, e.g. :9081")
+)
 
 func main() {
+	flag.Parse()
+
+	srv := server.New()
+	if *dataDir != "" {
+		db, err := storage.Open(filepath.Join(*dataDir, "products"))
+		if err != nil {
+			log.Fatalf("%v failed to open product store in %v: %v\n\n", tag, *dataDir, err)
+		}
+		defer db.Close()
+		if err := srv.Load(db); err != nil {
+			log.Fatalf("%v failed to load products: %v\n\n", tag, err)
+		}
+		log.Printf("%v Recovered %v products from %v\n", tag, srv.Len(), *dataDir)
+	}
+
 	lis, err := net.Listen("tcp", port)
 
 	if err != nil {
@@ -27,8 +51,43 @@ func main() {
 	}
 	log.Printf("%v Listening on port :%v\n\n", tag, port)
 
-	s := grpc.NewServer()
-	pb.RegisterProductInfoServer(s, &server{productMap: make(map[string]*pb.Product)})
+	v := server.NewValidator()
+	creds, err := serverTLS.Credentials()
+	if err != nil {
+		log.Fatalf("%v failed to load TLS credentials: %v\n\n", tag, err)
+	}
+	authenticator, err := serverAuth.Authenticator()
+	if err != nil {
+		log.Fatalf("%v failed to load authentication keys: %v\n\n", tag, err)
+	}
+	if authenticator == nil {
+		log.Printf("%v Authentication is disabled, every caller is admitted\n", tag)
+	}
+	observer := middleware.NewObserver(tag)
+	if *metricsAddr != "" {
+		go func() {
+			log.Fatalf("%v failed to serve metrics: %v\n\n", tag, middleware.ServeMetrics(*metricsAddr, observer))
+		}()
+		log.Printf("%v Serving metrics on %v/debug/vars\n", tag, *metricsAddr)
+	}
+	// Every call is observed, then recovered from panics. Callers are
+	// authenticated and authorized before their requests are validated.
+	s := grpc.NewServer(
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(
+			observer.UnaryServerInterceptor(),
+			middleware.UnaryServerRecovery(tag),
+			authenticator.UnaryServerInterceptor(server.Policy),
+			v.UnaryServerInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			observer.StreamServerInterceptor(),
+			middleware.StreamServerRecovery(tag),
+			authenticator.StreamServerInterceptor(server.Policy),
+			v.StreamServerInterceptor(),
+		),
+	)
+	pb.RegisterProductInfoServer(s, srv)
 
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("%v failed to serve: %v\n\n", tag, err)
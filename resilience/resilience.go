@@ -0,0 +1,212 @@
+// Package resilience protects the calls a service makes to the services it
+// depends on. Its client interceptor gives every attempt a deadline, retries
+// attempts that failed transiently with jittered exponential backoff, and
+// stops calling a dependency that keeps failing through a circuit breaker:
+//
+//	conn, err := grpc.Dial(addr,
+//		grpc.WithTransportCredentials(insecure.NewCredentials()),
+//		grpc.WithUnaryInterceptor(resilience.UnaryClientInterceptor(resilience.DefaultPolicy())))
+//
+// Only use it for idempotent methods, as an attempt that timed out may still
+// have been served.
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is returned, without calling the dependency, while the
+// circuit breaker is open. Its gRPC code is Unavailable.
+var ErrCircuitOpen = status.Error(codes.Unavailable, "circuit breaker is open")
+
+// Policy configures UnaryClientInterceptor.
+type Policy struct {
+	// Timeout is the deadline of each attempt, 0 for none. The deadline of
+	// the caller's context still bounds the call as a whole.
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts made, including the first one.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled for every further
+	// retry up to MaxBackoff. Delays are randomized by up to 50%.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Breaker, if set, is consulted before and told the outcome of every attempt.
+	Breaker *Breaker
+}
+
+// DefaultPolicy returns the policy used for calls between the services:
+// 500ms per attempt, 3 attempts, and a breaker opening for 10s after 5
+// consecutive failures.
+func DefaultPolicy() Policy {
+	return Policy{
+		Timeout:     500 * time.Millisecond,
+		MaxAttempts: 3,
+		Backoff:     50 * time.Millisecond,
+		MaxBackoff:  time.Second,
+		Breaker:     NewBreaker(5, 10*time.Second),
+	}
+}
+
+// retryable reports whether an attempt failing with c may succeed when repeated.
+func retryable(c codes.Code) bool {
+	switch c {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// UnaryClientInterceptor applies p to every unary call of a connection.
+func UnaryClientInterceptor(p Policy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		backoff := p.Backoff
+		var err error
+		for attempt := 1; ; attempt++ {
+			err = p.attempt(ctx, method, req, reply, cc, invoker, opts...)
+			if err == nil || !retryable(status.Code(err)) || errors.Is(err, ErrCircuitOpen) || attempt >= p.MaxAttempts {
+				return err
+			}
+
+			// Jitter in [backoff/2, backoff] spreads the retries of concurrent
+			// callers instead of having them hit the dependency in lockstep.
+			delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(delay):
+			}
+			if backoff *= 2; p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+				backoff = p.MaxBackoff
+			}
+		}
+	}
+}
+
+func (p Policy) attempt(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if p.Breaker != nil {
+		if err := p.Breaker.allow(); err != nil {
+			return err
+		}
+	}
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if p.Breaker != nil {
+		p.Breaker.record(err)
+	}
+	return err
+}
+
+type breakerState int
+
+const (
+	closed breakerState = iota
+	open
+	halfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case open:
+		return "open"
+	case halfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Breaker is a circuit breaker. It is closed, letting calls through, until
+// threshold consecutive calls failed in a way suggesting the dependency is
+// unhealthy. It then opens, failing calls immediately, for cooldown, after
+// which it lets a single probe call through: if that one succeeds the
+// breaker closes again, otherwise it reopens.
+//
+// A Breaker is safe for concurrent use.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	// OnStateChange, if set, is called with the new state on every
+	// transition. It runs with the breaker locked and must not use it.
+	OnStateChange func(state string)
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// NewBreaker returns a closed breaker.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// State returns "closed", "open" or "half-open".
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case open:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.setLocked(halfOpen)
+		return nil
+	case halfOpen:
+		// The probe is in flight.
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a call it allowed.
+// Errors the dependency answered on purpose, e.g. NotFound, count as success.
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch status.Code(err) {
+	case codes.Canceled:
+		// Says nothing about the dependency. A cancelled probe is retried
+		// by the next call.
+		if b.state == halfOpen {
+			b.setLocked(open)
+		}
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		b.failures++
+		if b.state == halfOpen || b.failures >= b.threshold {
+			b.openedAt = time.Now()
+			b.setLocked(open)
+		}
+	default:
+		b.failures = 0
+		if b.state != closed {
+			b.setLocked(closed)
+		}
+	}
+}
+
+func (b *Breaker) setLocked(s breakerState) {
+	b.state = s
+	if s == closed {
+		b.failures = 0
+	}
+	if b.OnStateChange != nil {
+		b.OnStateChange(s.String())
+	}
+}
@@ -0,0 +1,82 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invoker returns an invoker failing with the codes of fails in turn, then
+// succeeding, and counting its calls in calls.
+func invoker(calls *int, fails ...codes.Code) grpc.UnaryInvoker {
+	return func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		*calls++
+		if *calls <= len(fails) {
+			return status.Error(fails[*calls-1], "failed")
+		}
+		return nil
+	}
+}
+
+func TestRetries(t *testing.T) {
+	p := Policy{MaxAttempts: 3, Backoff: time.Millisecond}
+	tests := []struct {
+		name  string
+		fails []codes.Code
+		code  codes.Code
+		calls int
+	}{
+		{"success", nil, codes.OK, 1},
+		{"transient failures", []codes.Code{codes.Unavailable, codes.DeadlineExceeded}, codes.OK, 3},
+		{"too many failures", []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable}, codes.Unavailable, 3},
+		{"permanent failure", []codes.Code{codes.NotFound}, codes.NotFound, 1},
+	}
+	for _, test := range tests {
+		calls := 0
+		err := UnaryClientInterceptor(p)(context.Background(), "/m", nil, nil, nil, invoker(&calls, test.fails...))
+		if status.Code(err) != test.code || calls != test.calls {
+			t.Errorf("%v: got %v after %d calls, want %v after %d", test.name, err, calls, test.code, test.calls)
+		}
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	b := NewBreaker(2, 50*time.Millisecond)
+	var states []string
+	b.OnStateChange = func(state string) { states = append(states, state) }
+	call := UnaryClientInterceptor(Policy{MaxAttempts: 1, Breaker: b})
+
+	calls := 0
+	failing := invoker(&calls, codes.Unavailable, codes.Unavailable, codes.Unavailable)
+	for i := 0; i < 2; i++ {
+		call(context.Background(), "/m", nil, nil, nil, failing)
+	}
+	// Open: calls fail without reaching the dependency.
+	if err := call(context.Background(), "/m", nil, nil, nil, failing); err != ErrCircuitOpen || calls != 2 {
+		t.Fatalf("call while open = %v after %d calls, want ErrCircuitOpen after 2", err, calls)
+	}
+
+	// A failed probe reopens the breaker, a successful one closes it.
+	time.Sleep(60 * time.Millisecond)
+	call(context.Background(), "/m", nil, nil, nil, failing)
+	if b.State() != "open" {
+		t.Errorf("after a failed probe the breaker is %v, want open", b.State())
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err := call(context.Background(), "/m", nil, nil, nil, failing); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	want := []string{"open", "half-open", "open", "half-open", "closed"}
+	if len(states) != len(want) {
+		t.Fatalf("states = %q, want %q", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("states = %q, want %q", states, want)
+		}
+	}
+}
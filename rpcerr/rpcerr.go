@@ -0,0 +1,201 @@
+// Package rpcerr builds the gRPC status errors returned by the services and
+// renders them on the client side.
+//
+// Every error carries an errdetails.ErrorInfo whose Reason is one of the
+// Reason constants below, so that callers can branch on it instead of parsing
+// messages. Depending on the failure it is followed by a ResourceInfo naming
+// the resource involved, a BadRequest listing the invalid fields or a
+// PreconditionFailure.
+package rpcerr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Domain is the ErrorInfo domain of every error built by this package.
+const Domain = "ecommerce"
+
+// Reason codes carried in ErrorInfo.Reason.
+const (
+	ReasonNotFound           = "RESOURCE_NOT_FOUND"
+	ReasonAlreadyExists      = "RESOURCE_ALREADY_EXISTS"
+	ReasonVersionMismatch    = "VERSION_MISMATCH"
+	ReasonInvalidTransition  = "INVALID_STATUS_TRANSITION"
+	ReasonNotEditable        = "RESOURCE_NOT_EDITABLE"
+	ReasonInvalidRequest     = "INVALID_REQUEST"
+	ReasonInvalidArgument    = "INVALID_ARGUMENT"
+	ReasonInvalidPageToken   = "INVALID_PAGE_TOKEN"
+	ReasonUnknownProduct     = "UNKNOWN_PRODUCT"
+	ReasonCurrencyMismatch   = "CURRENCY_MISMATCH"
+	ReasonOutOfStock         = "OUT_OF_STOCK"
+	ReasonStockReserved      = "STOCK_RESERVED"
+	ReasonAlreadyInShipment  = "ALREADY_IN_SHIPMENT"
+	ReasonRevisionOutOfRange = "REVISION_OUT_OF_RANGE"
+	ReasonUnauthenticated    = "UNAUTHENTICATED"
+	ReasonPermissionDenied   = "PERMISSION_DENIED"
+	ReasonDependencyFailed   = "DEPENDENCY_FAILED"
+	ReasonInternal           = "INTERNAL"
+)
+
+// Status returns a status with code c and message msg whose details are an
+// ErrorInfo with reason and metadata, followed by details.
+func Status(c codes.Code, reason string, metadata map[string]string, msg string, details ...proto.Message) *status.Status {
+	st := &spb.Status{Code: int32(c), Message: msg}
+	details = append([]proto.Message{&errdetails.ErrorInfo{Reason: reason, Domain: Domain, Metadata: metadata}}, details...)
+	for _, d := range details {
+		a, err := anypb.New(d)
+		if err != nil {
+			continue
+		}
+		st.Details = append(st.Details, a)
+	}
+	return status.FromProto(st)
+}
+
+// resourceInfo describes the resource of type resourceType, a fully
+// qualified message name such as "ecommerce.Order", called name.
+func resourceInfo(resourceType, name, description string) *errdetails.ResourceInfo {
+	return &errdetails.ResourceInfo{ResourceType: resourceType, ResourceName: name, Description: description}
+}
+
+// displayName returns the unqualified message name of resourceType.
+func displayName(resourceType string) string {
+	return resourceType[strings.LastIndex(resourceType, ".")+1:]
+}
+
+// NotFound reports that the resource called name does not exist.
+func NotFound(resourceType, name string) error {
+	msg := fmt.Sprintf("%v %v does not exist", displayName(resourceType), name)
+	return Status(codes.NotFound, ReasonNotFound, nil, msg, resourceInfo(resourceType, name, msg)).Err()
+}
+
+// AlreadyExists reports that a resource called name exists already.
+func AlreadyExists(resourceType, name string) error {
+	msg := fmt.Sprintf("%v %v already exists", displayName(resourceType), name)
+	return Status(codes.AlreadyExists, ReasonAlreadyExists, nil, msg, resourceInfo(resourceType, name, msg)).Err()
+}
+
+// VersionMismatch reports a failed optimistic concurrency check.
+func VersionMismatch(resourceType, name string, current, expected int64) error {
+	msg := fmt.Sprintf("%v %v is at version %v, expected version %v", displayName(resourceType), name, current, expected)
+	metadata := map[string]string{
+		"current_version":  fmt.Sprint(current),
+		"expected_version": fmt.Sprint(expected),
+	}
+	return Status(codes.Aborted, ReasonVersionMismatch, metadata, msg, resourceInfo(resourceType, name, msg)).Err()
+}
+
+// FailedPrecondition reports that the resource called name is not in a
+// state that allows the operation.
+func FailedPrecondition(reason, resourceType, name string, metadata map[string]string, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	return Status(codes.FailedPrecondition, reason, metadata, msg,
+		resourceInfo(resourceType, name, msg),
+		&errdetails.PreconditionFailure{Violations: []*errdetails.PreconditionFailure_Violation{
+			{Type: reason, Subject: resourceType + "/" + name, Description: msg},
+		}}).Err()
+}
+
+// PreconditionFailed reports several failed preconditions at once, e.g.
+// every product of a request that is out of stock.
+func PreconditionFailed(reason string, metadata map[string]string, msg string, violations ...*errdetails.PreconditionFailure_Violation) error {
+	return Status(codes.FailedPrecondition, reason, metadata, msg, &errdetails.PreconditionFailure{Violations: violations}).Err()
+}
+
+// BadRequest reports the given invalid fields of a request.
+func BadRequest(reason, msg string, violations ...*errdetails.BadRequest_FieldViolation) *status.Status {
+	return Status(codes.InvalidArgument, reason, nil, msg, &errdetails.BadRequest{FieldViolations: violations})
+}
+
+// InvalidArgument reports a single invalid request field.
+func InvalidArgument(reason, field string, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	return BadRequest(reason, msg, &errdetails.BadRequest_FieldViolation{Field: field, Description: msg}).Err()
+}
+
+// Unauthenticated reports a request without valid credentials.
+func Unauthenticated(format string, args ...interface{}) error {
+	return Status(codes.Unauthenticated, ReasonUnauthenticated, nil, fmt.Sprintf(format, args...)).Err()
+}
+
+// PermissionDenied reports that subject lacks the roles required to call method.
+func PermissionDenied(subject, method string, roles []string) error {
+	metadata := map[string]string{"method": method, "required_roles": strings.Join(roles, ",")}
+	msg := fmt.Sprintf("%v may not call %v, which requires one of the roles %v", subject, method, strings.Join(roles, ", "))
+	return Status(codes.PermissionDenied, ReasonPermissionDenied, metadata, msg).Err()
+}
+
+// DependencyFailed reports that service, another service needed to serve
+// the request, failed with err. The code of err is kept when it is one a
+// retry may fix, otherwise the failure is reported as Unavailable.
+func DependencyFailed(service string, err error) error {
+	c := status.Code(err)
+	switch c {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+	default:
+		c = codes.Unavailable
+	}
+	msg := fmt.Sprintf("%v: %v", service, status.Convert(err).Message())
+	return Status(c, ReasonDependencyFailed, map[string]string{"service": service}, msg).Err()
+}
+
+// Internal reports a server side failure, e.g. of the storage backend.
+func Internal(format string, args ...interface{}) error {
+	return Status(codes.Internal, ReasonInternal, nil, fmt.Sprintf(format, args...)).Err()
+}
+
+// Reason returns the ErrorInfo reason of err, or "" if it has none.
+func Reason(err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+// Describe renders err with its code, message and one line per detail.
+// It is meant for logging on the client side.
+func Describe(err error) string {
+	st := status.Convert(err)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v: %v", st.Code(), st.Message())
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			fmt.Fprintf(&b, "\n\treason %v (%v)", d.Reason, d.Domain)
+			keys := make([]string, 0, len(d.Metadata))
+			for k := range d.Metadata {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Fprintf(&b, " %v=%v", k, d.Metadata[k])
+			}
+		case *errdetails.ResourceInfo:
+			fmt.Fprintf(&b, "\n\tresource %v %q", d.ResourceType, d.ResourceName)
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				fmt.Fprintf(&b, "\n\tfield %v: %v", v.Field, v.Description)
+			}
+		case *errdetails.PreconditionFailure:
+			for _, v := range d.Violations {
+				fmt.Fprintf(&b, "\n\tprecondition %v on %v: %v", v.Type, v.Subject, v.Description)
+			}
+		case error:
+			fmt.Fprintf(&b, "\n\tundecodable detail: %v", d)
+		default:
+			fmt.Fprintf(&b, "\n\t%T: %v", d, d)
+		}
+	}
+	return b.String()
+}
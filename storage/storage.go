@@ -0,0 +1,469 @@
+// Package storage is a small embedded key/value store used by the services
+// to survive restarts. Every mutation is appended to a write-ahead log and
+// fsynced before it is acknowledged; the log is periodically folded into a
+// snapshot so that recovery time stays bounded.
+//
+// On-disk layout of a data directory:
+//
+//	snapshot   full copy of the key space at the time of the last compaction
+//	wal        records written since that snapshot
+//
+// Both files use the same framing: a little endian CRC32 (Castagnoli) and
+// length header followed by the record payload. A torn record at the tail of
+// the log, as left behind by a crash mid-write, is discarded on open; any
+// other damage makes Open fail with ErrCorrupt.
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	walName      = "wal"
+	snapshotName = "snapshot"
+	headerSize   = 8
+
+	// maxRecordSize bounds the payload of a record, so that a damaged
+	// length is not trusted with an allocation of up to 4GB.
+	maxRecordSize = 16 << 20
+
+	opPut    byte = 1
+	opDelete byte = 2
+	opBatch  byte = 3
+
+	// DefaultSnapshotEvery is the number of logged writes after which the
+	// log is compacted into a new snapshot.
+	DefaultSnapshotEvery = 1000
+)
+
+var (
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// ErrClosed is returned by writes on a closed DB.
+	ErrClosed = errors.New("storage: db is closed")
+	// ErrCorrupt is returned by Open when the snapshot or a record of the
+	// log before its tail is damaged: it fails its checksum, is larger than
+	// a record may be, or cannot be applied.
+	ErrCorrupt = errors.New("storage: corrupt data")
+	// ErrTooLarge is returned by writes whose record would exceed the
+	// maximum size of a record.
+	ErrTooLarge = errors.New("storage: record too large")
+)
+
+// DB is a durable map from string keys to opaque values.
+// It is safe for concurrent use.
+type DB struct {
+	mu            sync.RWMutex
+	dir           string
+	wal           *os.File
+	data          map[string][]byte
+	logged        int
+	snapshotEvery int
+	// failed is set when the log could not be restored after a failed
+	// write, and then returned by every further write.
+	failed error
+}
+
+// Option configures a DB at Open time.
+type Option func(*DB)
+
+// WithSnapshotEvery sets how many logged writes trigger a compaction.
+// A value <= 0 disables automatic snapshots.
+func WithSnapshotEvery(n int) Option {
+	return func(db *DB) { db.snapshotEvery = n }
+}
+
+// Open opens (creating if needed) the store in dir and replays the snapshot
+// and write-ahead log found there.
+func Open(dir string, opts ...Option) (*DB, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	db := &DB{dir: dir, data: make(map[string][]byte), snapshotEvery: DefaultSnapshotEvery}
+	for _, opt := range opts {
+		opt(db)
+	}
+
+	if err := db.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := db.replayWAL(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *DB) loadSnapshot() error {
+	f, err := os.Open(filepath.Join(db.dir, snapshotName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Snapshots are renamed into place once complete, so even a torn record
+	// is damage here.
+	_, err = readRecords(bufio.NewReader(f), info.Size(), db.apply)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %v: %v", ErrCorrupt, snapshotName, err)
+	}
+	return err
+}
+
+func (db *DB) replayWAL() error {
+	f, err := os.OpenFile(filepath.Join(db.dir, walName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	good, err := readRecords(bufio.NewReader(f), info.Size(), func(payload []byte) error {
+		db.logged++
+		return db.apply(payload)
+	})
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		// Everything after the last intact record is a torn write.
+		if err := f.Truncate(good); err != nil {
+			f.Close()
+			return err
+		}
+	case err != nil:
+		f.Close()
+		return err
+	}
+	if _, err := f.Seek(good, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	db.wal = f
+	return nil
+}
+
+// readRecords calls fn for every record of the size bytes of r and returns
+// the offset just past the last one applied. It fails with an error wrapping
+// io.ErrUnexpectedEOF if the last record is torn: cut short by the end of r,
+// or failing its checksum with nothing after it, as left by a crash
+// mid-write. Any other damage, or fn failing, is reported as ErrCorrupt.
+func readRecords(r io.Reader, size int64, fn func(payload []byte) error) (int64, error) {
+	var offset int64
+	header := make([]byte, headerSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return offset, nil
+			}
+			return offset, err
+		}
+		sum := binary.LittleEndian.Uint32(header[0:4])
+		n := int64(binary.LittleEndian.Uint32(header[4:8]))
+		remaining := size - offset - headerSize
+		switch {
+		case n > maxRecordSize:
+			return offset, fmt.Errorf("%w: record of %d bytes at offset %d", ErrCorrupt, n, offset)
+		case n > remaining:
+			return offset, fmt.Errorf("record at offset %d: %w", offset, io.ErrUnexpectedEOF)
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return offset, err
+		}
+		if crc32.Checksum(payload, crcTable) != sum {
+			if n == remaining {
+				return offset, fmt.Errorf("checksum mismatch of the last record at offset %d: %w", offset, io.ErrUnexpectedEOF)
+			}
+			return offset, fmt.Errorf("%w: checksum mismatch at offset %d", ErrCorrupt, offset)
+		}
+		if err := fn(payload); err != nil {
+			return offset, fmt.Errorf("%w: record at offset %d: %v", ErrCorrupt, offset, err)
+		}
+		offset += headerSize + n
+	}
+}
+
+func (db *DB) apply(payload []byte) error {
+	if len(payload) == 0 {
+		return errors.New("empty record")
+	}
+	op := payload[0]
+	if op == opBatch {
+		for rest := payload[1:]; len(rest) > 0; {
+			size, n := binary.Uvarint(rest)
+			if n <= 0 || uint64(len(rest)-n) < size {
+				return errors.New("malformed batch")
+			}
+			if err := db.apply(rest[n : n+int(size)]); err != nil {
+				return err
+			}
+			rest = rest[n+int(size):]
+		}
+		return nil
+	}
+
+	keyLen, n := binary.Uvarint(payload[1:])
+	if n <= 0 || uint64(len(payload)-1-n) < keyLen {
+		return errors.New("malformed record")
+	}
+	key := string(payload[1+n : 1+n+int(keyLen)])
+	value := payload[1+n+int(keyLen):]
+
+	switch op {
+	case opPut:
+		db.data[key] = value
+	case opDelete:
+		delete(db.data, key)
+	default:
+		return fmt.Errorf("unknown op %d", op)
+	}
+	return nil
+}
+
+func encodePayload(op byte, key string, value []byte) []byte {
+	payload := make([]byte, 0, 1+binary.MaxVarintLen64+len(key)+len(value))
+	payload = append(payload, op)
+	payload = binary.AppendUvarint(payload, uint64(len(key)))
+	payload = append(payload, key...)
+	return append(payload, value...)
+}
+
+func encodeRecord(op byte, key string, value []byte) []byte {
+	return frame(encodePayload(op, key, value))
+}
+
+func frame(payload []byte) []byte {
+	rec := make([]byte, headerSize, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(rec[0:4], crc32.Checksum(payload, crcTable))
+	binary.LittleEndian.PutUint32(rec[4:8], uint32(len(payload)))
+	return append(rec, payload...)
+}
+
+// Get returns the value stored under key. The returned slice must not be modified.
+func (db *DB) Get(key string) ([]byte, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	value, exists := db.data[key]
+	return value, exists
+}
+
+// Len returns the number of stored keys.
+func (db *DB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return len(db.data)
+}
+
+// Range calls fn for every key until fn returns false. The DB is read locked
+// for the duration of the call, so fn must not write to it.
+func (db *DB) Range(fn func(key string, value []byte) bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for k, v := range db.data {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// Put durably stores value under key.
+func (db *DB) Put(key string, value []byte) error {
+	v := make([]byte, len(value))
+	copy(v, value)
+	return db.write(encodeRecord(opPut, key, v), func() { db.data[key] = v })
+}
+
+// Delete durably removes key.
+func (db *DB) Delete(key string) error {
+	return db.write(encodeRecord(opDelete, key, nil), func() { delete(db.data, key) })
+}
+
+// Batch is a group of writes that DB.Write logs as a single record,
+// so that after a crash either all or none of them are recovered.
+type Batch struct {
+	payloads [][]byte
+	applies  []func(data map[string][]byte)
+}
+
+// Put adds a write of value under key to the batch.
+func (b *Batch) Put(key string, value []byte) {
+	v := make([]byte, len(value))
+	copy(v, value)
+	b.payloads = append(b.payloads, encodePayload(opPut, key, v))
+	b.applies = append(b.applies, func(data map[string][]byte) { data[key] = v })
+}
+
+// Delete adds the removal of key to the batch.
+func (b *Batch) Delete(key string) {
+	b.payloads = append(b.payloads, encodePayload(opDelete, key, nil))
+	b.applies = append(b.applies, func(data map[string][]byte) { delete(data, key) })
+}
+
+// Len returns the number of writes in the batch.
+func (b *Batch) Len() int {
+	return len(b.payloads)
+}
+
+// Write durably and atomically applies every write of b, in order.
+func (db *DB) Write(b *Batch) error {
+	if b.Len() == 0 {
+		return nil
+	}
+	payload := []byte{opBatch}
+	for _, p := range b.payloads {
+		payload = binary.AppendUvarint(payload, uint64(len(p)))
+		payload = append(payload, p...)
+	}
+	return db.write(frame(payload), func() {
+		for _, apply := range b.applies {
+			apply(db.data)
+		}
+	})
+}
+
+func (db *DB) write(rec []byte, apply func()) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.wal == nil {
+		return ErrClosed
+	}
+	if db.failed != nil {
+		return db.failed
+	}
+	if len(rec)-headerSize > maxRecordSize {
+		return fmt.Errorf("%w: %d bytes, at most %d", ErrTooLarge, len(rec)-headerSize, maxRecordSize)
+	}
+
+	offset, err := db.wal.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := db.wal.Write(rec); err != nil {
+		return db.rollbackLocked(offset, err)
+	}
+	if err := db.wal.Sync(); err != nil {
+		return db.rollbackLocked(offset, err)
+	}
+	apply()
+
+	db.logged++
+	if db.snapshotEvery > 0 && db.logged >= db.snapshotEvery {
+		// The write is durable and applied whatever happens to the snapshot.
+		// The log is kept as is and compaction retried after as many writes.
+		if err := db.snapshotLocked(); err != nil {
+			log.Printf("storage: %v: snapshot failed, keeping the log: %v\n", db.dir, err)
+			db.logged = 0
+		}
+	}
+	return nil
+}
+
+// rollbackLocked cuts the log back to offset after a write failed with err,
+// so that no part of the record precedes the records written next: replay
+// stops at the first torn record and would lose them. If that fails too,
+// the DB refuses further writes.
+func (db *DB) rollbackLocked(offset int64, err error) error {
+	if terr := db.wal.Truncate(offset); terr != nil {
+		db.failed = fmt.Errorf("storage: log unusable after failed write (%v): %w", err, terr)
+		return db.failed
+	}
+	if _, serr := db.wal.Seek(offset, io.SeekStart); serr != nil {
+		db.failed = fmt.Errorf("storage: log unusable after failed write (%v): %w", err, serr)
+		return db.failed
+	}
+	return err
+}
+
+// Snapshot writes the current key space to a new snapshot and truncates the log.
+func (db *DB) Snapshot() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.wal == nil {
+		return ErrClosed
+	}
+	return db.snapshotLocked()
+}
+
+func (db *DB) snapshotLocked() error {
+	tmp, err := os.CreateTemp(db.dir, snapshotName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for k, v := range db.data {
+		if _, err := w.Write(encodeRecord(opPut, k, v)); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(db.dir, snapshotName)); err != nil {
+		return err
+	}
+	if err := syncDir(db.dir); err != nil {
+		return err
+	}
+
+	// The snapshot now covers everything in the log. Replaying the old log on
+	// top of it is harmless, so a crash before this point loses nothing.
+	if err := db.wal.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := db.wal.Seek(0, io.SeekStart); err != nil {
+		// Further records would follow a gap at the old end of the log.
+		db.failed = fmt.Errorf("storage: log unusable after snapshot: %w", err)
+		return db.failed
+	}
+	db.logged = 0
+	return db.wal.Sync()
+}
+
+// Close releases the log file. The DB must not be used afterwards.
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.wal == nil {
+		return nil
+	}
+	err := db.wal.Close()
+	db.wal = nil
+	return err
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func open(t *testing.T, dir string, opts ...Option) *DB {
+	t.Helper()
+	db, err := Open(dir, opts...)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func put(t *testing.T, db *DB, key, value string) {
+	t.Helper()
+	if err := db.Put(key, []byte(value)); err != nil {
+		t.Fatalf("Put %v: %v", key, err)
+	}
+}
+
+// checkData checks that db holds exactly want.
+func checkData(t *testing.T, db *DB, want map[string]string) {
+	t.Helper()
+	if db.Len() != len(want) {
+		t.Errorf("Len = %d, want %d", db.Len(), len(want))
+	}
+	for key, value := range want {
+		if got, ok := db.Get(key); !ok || string(got) != value {
+			t.Errorf("Get(%q) = %q, %v, want %q", key, got, ok, value)
+		}
+	}
+}
+
+func walSize(t *testing.T, dir string) int64 {
+	t.Helper()
+	info, err := os.Stat(filepath.Join(dir, walName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir)
+	put(t, db, "a", "1")
+	put(t, db, "b", "2")
+	put(t, db, "a", "3")
+	if err := db.Delete("b"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	db.Close()
+	if err := db.Put("c", nil); err != ErrClosed {
+		t.Errorf("Put after Close = %v, want ErrClosed", err)
+	}
+
+	checkData(t, open(t, dir), map[string]string{"a": "3"})
+}
+
+func TestReopenAfterTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir)
+	put(t, db, "a", "1")
+	put(t, db, "b", "2")
+	db.Close()
+	good := walSize(t, dir)
+
+	// A crash in the middle of writing a record leaves part of it behind.
+	torn := encodeRecord(opPut, "c", []byte("3"))
+	for _, n := range []int{3, headerSize, len(torn) - 1} {
+		f, err := os.OpenFile(filepath.Join(dir, walName), os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(torn[:n])
+		f.Close()
+
+		db = open(t, dir)
+		checkData(t, db, map[string]string{"a": "1", "b": "2"})
+		if size := walSize(t, dir); size != good {
+			t.Errorf("%d torn bytes: log is %d bytes after Open, want %d", n, size, good)
+		}
+		db.Close()
+	}
+
+	// The log keeps working after the torn record.
+	db = open(t, dir)
+	put(t, db, "c", "3")
+	db.Close()
+	checkData(t, open(t, dir), map[string]string{"a": "1", "b": "2", "c": "3"})
+}
+
+func TestReopenAfterTornChecksum(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir)
+	put(t, db, "a", "1")
+	put(t, db, "b", "2")
+	db.Close()
+
+	// The last record reached its full length, but not all of its bytes
+	// made it to the disk.
+	file := filepath.Join(dir, walName)
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := len(encodeRecord(opPut, "b", []byte("2")))
+	b[len(b)-1] ^= 0xff
+	if err := os.WriteFile(file, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checkData(t, open(t, dir), map[string]string{"a": "1"})
+	if size := walSize(t, dir); size != int64(len(b)-last) {
+		t.Errorf("log is %d bytes after Open, want %d", size, len(b)-last)
+	}
+}
+
+func TestCorruptLog(t *testing.T) {
+	write := func(t *testing.T) (dir string, b []byte) {
+		dir = t.TempDir()
+		db := open(t, dir)
+		put(t, db, "a", "1")
+		put(t, db, "b", "2")
+		put(t, db, "c", "3")
+		db.Close()
+		b, err := os.ReadFile(filepath.Join(dir, walName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dir, b
+	}
+	second := len(encodeRecord(opPut, "a", []byte("1"))) // offset of the record of b
+	tests := []struct {
+		name    string
+		corrupt func(b []byte)
+	}{
+		{"checksum", func(b []byte) { b[second+headerSize+2] ^= 0xff }},
+		{"size over the maximum", func(b []byte) { binary.LittleEndian.PutUint32(b[second+4:], maxRecordSize+1) }},
+		{"unknown op", func(b []byte) { copy(b[second:], frame([]byte{9, 1, 'b', '2'})) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, b := write(t)
+			test.corrupt(b)
+			if err := os.WriteFile(filepath.Join(dir, walName), b, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			// Records follow the damaged one: it is not a torn write, and
+			// the log is left alone rather than cut back to the damage.
+			if _, err := Open(dir); !errors.Is(err, ErrCorrupt) {
+				t.Errorf("Open = %v, want ErrCorrupt", err)
+			}
+			if size := walSize(t, dir); size != int64(len(b)) {
+				t.Errorf("log is %d bytes after Open, want %d", size, len(b))
+			}
+		})
+	}
+}
+
+func TestWriteTooLarge(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir)
+	if err := db.Put("big", make([]byte, maxRecordSize)); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Put of %d bytes = %v, want ErrTooLarge", maxRecordSize, err)
+	}
+	put(t, db, "a", "1")
+	db.Close()
+	checkData(t, open(t, dir), map[string]string{"a": "1"})
+}
+
+func TestSnapshotAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir, WithSnapshotEvery(3))
+	put(t, db, "a", "1")
+	put(t, db, "b", "2")
+	put(t, db, "c", "3") // folded into the snapshot
+	if size := walSize(t, dir); size != 0 {
+		t.Errorf("log is %d bytes after a snapshot, want 0", size)
+	}
+	put(t, db, "a", "4")
+	if err := db.Delete("b"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	db.Close()
+
+	// The writes logged after the snapshot are replayed on top of it.
+	want := map[string]string{"a": "4", "c": "3"}
+	db = open(t, dir, WithSnapshotEvery(0))
+	checkData(t, db, want)
+	if err := db.Snapshot(); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	db.Close()
+	checkData(t, open(t, dir), want)
+}
+
+func TestCorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir)
+	put(t, db, "a", "1")
+	if err := db.Snapshot(); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	db.Close()
+
+	file := filepath.Join(dir, snapshotName)
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(b)-1] ^= 0xff
+	if err := os.WriteFile(file, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Open = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestBatchIsAtomicAcrossCrash(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir)
+	put(t, db, "a", "1")
+	b := new(Batch)
+	b.Put("b", []byte("2"))
+	b.Delete("a")
+	b.Put("c", []byte("3"))
+	if err := db.Write(b); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	db.Close()
+	checkData(t, open(t, dir), map[string]string{"b": "2", "c": "3"})
+
+	// A crash before the whole batch record reached the disk recovers none of
+	// its writes.
+	if err := os.Truncate(filepath.Join(dir, walName), walSize(t, dir)-1); err != nil {
+		t.Fatal(err)
+	}
+	checkData(t, open(t, dir), map[string]string{"a": "1"})
+}
+
+func TestWriteKeptWhenSnapshotFails(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir, WithSnapshotEvery(1))
+
+	// A directory in the way of the snapshot makes it fail, but not the
+	// write, which is already in the log.
+	blocker := filepath.Join(dir, snapshotName, "blocker")
+	if err := os.MkdirAll(blocker, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	put(t, db, "a", "1")
+	put(t, db, "b", "2")
+	checkData(t, db, map[string]string{"a": "1", "b": "2"})
+	db.Close()
+
+	if err := os.RemoveAll(filepath.Join(dir, snapshotName)); err != nil {
+		t.Fatal(err)
+	}
+	checkData(t, open(t, dir), map[string]string{"a": "1", "b": "2"})
+}
+
+func TestFailedLogRefusesWrites(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir)
+	put(t, db, "a", "1")
+
+	// A log that can be neither written nor cut back leaves the DB failed.
+	f, err := os.Open(filepath.Join(dir, walName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	db.wal.Close()
+	db.wal = f
+	err = db.Put("b", []byte("2"))
+	if err == nil {
+		t.Fatal("Put to a read-only log succeeded")
+	}
+	if again := db.Put("c", []byte("3")); again != err {
+		t.Errorf("Put after a failed write = %v, want %v", again, err)
+	}
+	if again := db.Delete("a"); again != err {
+		t.Errorf("Delete after a failed write = %v, want %v", again, err)
+	}
+	checkData(t, db, map[string]string{"a": "1"})
+	db.Close()
+
+	checkData(t, open(t, dir), map[string]string{"a": "1"})
+}
@@ -0,0 +1,136 @@
+// Package tlsconfig builds the transport credentials of the servers and
+// clients from PEM files, such as the ones cmd/gencerts writes into ssl/.
+//
+// Every setting comes from command line flags registered by ServerFlags and
+// ClientFlags. Without a certificate, servers and clients fall back to
+// plaintext, so the services keep running as before until TLS is configured:
+//
+//	tlsFlags := tlsconfig.ServerFlags("")
+//	flag.Parse()
+//	creds, err := tlsFlags.Credentials()
+//	s := grpc.NewServer(grpc.Creds(creds))
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Server holds the TLS settings of a server.
+type Server struct {
+	// CertFile and KeyFile hold the certificate of the server and its key.
+	// TLS is off when CertFile is empty.
+	CertFile, KeyFile string
+	// ClientCAFile, if set, enables mutual TLS: clients must present a
+	// certificate signed by one of the CAs in this file.
+	ClientCAFile string
+}
+
+// ServerFlags registers the -<prefix>tls-cert, -<prefix>tls-key and
+// -<prefix>tls-client-ca flags and returns the settings they fill in.
+func ServerFlags(prefix string) *Server {
+	s := &Server{}
+	flag.StringVar(&s.CertFile, prefix+"tls-cert", "", "PEM certificate of the server; serves plaintext when empty")
+	flag.StringVar(&s.KeyFile, prefix+"tls-key", "", "PEM private key of the server certificate")
+	flag.StringVar(&s.ClientCAFile, prefix+"tls-client-ca", "", "PEM CA certificates of the client certificates to require (mutual TLS)")
+	return s
+}
+
+// Enabled reports whether TLS is configured.
+func (s *Server) Enabled() bool {
+	return s.CertFile != ""
+}
+
+// Credentials returns the credentials to pass to grpc.Creds.
+func (s *Server) Credentials() (credentials.TransportCredentials, error) {
+	if !s.Enabled() {
+		if s.ClientCAFile != "" {
+			return nil, errors.New("tlsconfig: a client CA requires a server certificate")
+		}
+		return insecure.NewCredentials(), nil
+	}
+	cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tlsconfig: loading server certificate: %w", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if s.ClientCAFile != "" {
+		if config.ClientCAs, err = loadCertPool(s.ClientCAFile); err != nil {
+			return nil, err
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// Client holds the TLS settings of a client.
+type Client struct {
+	// CAFile holds the CA certificates the server certificate is verified
+	// against. TLS is off when CAFile is empty.
+	CAFile string
+	// CertFile and KeyFile hold the certificate presented to servers that
+	// require mutual TLS, and its key.
+	CertFile, KeyFile string
+	// ServerName overrides the name the server certificate is checked for,
+	// by default the host of the address dialed.
+	ServerName string
+}
+
+// ClientFlags registers the -<prefix>tls-ca, -<prefix>tls-cert,
+// -<prefix>tls-key and -<prefix>tls-server-name flags and returns the
+// settings they fill in.
+func ClientFlags(prefix string) *Client {
+	c := &Client{}
+	flag.StringVar(&c.CAFile, prefix+"tls-ca", "", "PEM CA certificates to verify the server with; dials plaintext when empty")
+	flag.StringVar(&c.CertFile, prefix+"tls-cert", "", "PEM client certificate, for servers requiring mutual TLS")
+	flag.StringVar(&c.KeyFile, prefix+"tls-key", "", "PEM private key of the client certificate")
+	flag.StringVar(&c.ServerName, prefix+"tls-server-name", "", "name to verify the server certificate for instead of the dialed host")
+	return c
+}
+
+// Enabled reports whether TLS is configured.
+func (c *Client) Enabled() bool {
+	return c.CAFile != ""
+}
+
+// Credentials returns the credentials to pass to grpc.WithTransportCredentials.
+func (c *Client) Credentials() (credentials.TransportCredentials, error) {
+	if !c.Enabled() {
+		if c.CertFile != "" {
+			return nil, errors.New("tlsconfig: a client certificate requires a CA to verify the server with")
+		}
+		return insecure.NewCredentials(), nil
+	}
+	roots, err := loadCertPool(c.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{RootCAs: roots, ServerName: c.ServerName, MinVersion: tls.VersionTLS12}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tlsconfig: loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("tlsconfig: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("tlsconfig: no PEM certificate in %v", file)
+	}
+	return pool, nil
+}
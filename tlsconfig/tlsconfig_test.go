@@ -0,0 +1,151 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// issuer signs the certificates of a test.
+type issuer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue writes the certificate of name, signed by parent or self-signed if
+// nil, and its key to dir as name.crt and name.key.
+func issue(t *testing.T, dir, name string, parent *issuer, template *x509.Certificate) *issuer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if template.SerialNumber, err = rand.Int(rand.Reader, big.NewInt(1<<62)); err != nil {
+		t.Fatal(err)
+	}
+	template.Subject = pkix.Name{CommonName: name}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signer := &issuer{cert: template, key: key}
+	if parent != nil {
+		signer = parent
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer.cert, &key.PublicKey, signer.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, name+".crt"), "CERTIFICATE", der)
+	writePEM(t, filepath.Join(dir, name+".key"), "PRIVATE KEY", keyDer)
+	return &issuer{cert: cert, key: key}
+}
+
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newCA(t *testing.T, dir, name string) *issuer {
+	return issue(t, dir, name, nil, &x509.Certificate{
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+}
+
+func newLeaf(t *testing.T, dir, name string, ca *issuer, usage x509.ExtKeyUsage) {
+	issue(t, dir, name, ca, &x509.Certificate{
+		DNSNames:    []string{name},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{usage},
+	})
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t, dir, "ca")
+	newLeaf(t, dir, "server", ca, x509.ExtKeyUsageServerAuth)
+	newLeaf(t, dir, "client", ca, x509.ExtKeyUsageClientAuth)
+	rogue := newCA(t, dir, "rogue-ca")
+	newLeaf(t, dir, "rogue", rogue, x509.ExtKeyUsageClientAuth)
+	file := func(name string) string { return filepath.Join(dir, name) }
+
+	creds, err := (&Server{CertFile: file("server.crt"), KeyFile: file("server.key"), ClientCAFile: file("ca.crt")}).Credentials()
+	if err != nil {
+		t.Fatalf("server credentials: %v", err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(grpc.Creds(creds))
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(lis)
+	defer s.Stop()
+
+	for _, test := range []struct {
+		name   string
+		client *Client
+		want   codes.Code
+	}{
+		{"trusted certificate", &Client{CAFile: file("ca.crt"), CertFile: file("client.crt"), KeyFile: file("client.key"), ServerName: "server"}, codes.OK},
+		{"no certificate", &Client{CAFile: file("ca.crt"), ServerName: "server"}, codes.Unavailable},
+		{"untrusted certificate", &Client{CAFile: file("ca.crt"), CertFile: file("rogue.crt"), KeyFile: file("rogue.key"), ServerName: "server"}, codes.Unavailable},
+		{"untrusted server", &Client{CAFile: file("rogue-ca.crt"), CertFile: file("client.crt"), KeyFile: file("client.key"), ServerName: "server"}, codes.Unavailable},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			clientCreds, err := test.client.Credentials()
+			if err != nil {
+				t.Fatalf("client credentials: %v", err)
+			}
+			conn, err := grpc.Dial("bufconn",
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+					return lis.DialContext(ctx)
+				}),
+				grpc.WithTransportCredentials(clientCreds))
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+			if got := status.Code(err); got != test.want {
+				t.Errorf("check: got %v (%v), want %v", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestCredentialsRequireCertificates(t *testing.T) {
+	if _, err := (&Server{ClientCAFile: "ca.crt"}).Credentials(); err == nil {
+		t.Error("server: a client CA without a server certificate was accepted")
+	}
+	if _, err := (&Client{CertFile: "client.crt", KeyFile: "client.key"}).Credentials(); err == nil {
+		t.Error("client: a client certificate without a CA was accepted")
+	}
+}
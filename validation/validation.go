@@ -0,0 +1,289 @@
+// Package validation checks incoming request messages against declarative,
+// per message type rules and rejects invalid ones with InvalidArgument and
+// an errdetails.BadRequest listing every violated field, built by rpcerr.
+//
+// Rules are registered once per message type and enforced for every method
+// of a server by the interceptors of this package:
+//
+//	v := validation.NewRegistry()
+//	v.Register(&pb.Product{}, validation.Required("name"), validation.Positive("price"))
+//	s := grpc.NewServer(
+//		grpc.UnaryInterceptor(v.UnaryServerInterceptor()),
+//		grpc.StreamInterceptor(v.StreamServerInterceptor()))
+package validation
+
+import (
+	"context"
+	"ecommerce/money"
+	"ecommerce/rpcerr"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	moneypb "google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Violation is a single invalid field.
+type Violation = errdetails.BadRequest_FieldViolation
+
+// Rule checks a message and returns the fields it finds invalid.
+type Rule func(m protoreflect.Message) []*Violation
+
+// Registry holds the rules of each message type.
+type Registry struct {
+	rules map[protoreflect.FullName][]Rule
+}
+
+func NewRegistry() *Registry {
+	return &Registry{rules: make(map[protoreflect.FullName][]Rule)}
+}
+
+// Register adds rules for messages of the same type as msg.
+func (r *Registry) Register(msg proto.Message, rules ...Rule) {
+	name := msg.ProtoReflect().Descriptor().FullName()
+	r.rules[name] = append(r.rules[name], rules...)
+}
+
+// Validate returns nil if msg satisfies every rule registered for its type,
+// and an *Error otherwise. Messages without rules are always valid.
+func (r *Registry) Validate(msg proto.Message) error {
+	m := msg.ProtoReflect()
+	var violations []*Violation
+	for _, rule := range r.rules[m.Descriptor().FullName()] {
+		violations = append(violations, rule(m)...)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return newError(msg, violations)
+}
+
+// Error is returned for an invalid message. It carries the message, so that
+// streaming handlers can report which item of the stream was rejected and
+// carry on with the next one, and converts to an InvalidArgument status.
+type Error struct {
+	Message proto.Message
+	status  *status.Status
+}
+
+func newError(msg proto.Message, violations []*Violation) *Error {
+	descs := make([]string, len(violations))
+	for i, v := range violations {
+		descs[i] = v.Field + ": " + v.Description
+	}
+	st := rpcerr.BadRequest(rpcerr.ReasonInvalidRequest, fmt.Sprintf("invalid %v: %v",
+		msg.ProtoReflect().Descriptor().Name(), strings.Join(descs, "; ")), violations...)
+	return &Error{Message: msg, status: st}
+}
+
+func (e *Error) Error() string {
+	return e.status.Err().Error()
+}
+
+// GRPCStatus lets status.FromError and status.Code see the InvalidArgument status.
+func (e *Error) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// UnaryServerInterceptor rejects invalid requests before they reach the handler.
+func (r *Registry) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if msg, ok := req.(proto.Message); ok {
+			if err := r.Validate(msg); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor validates every message received on a stream.
+// An invalid message makes RecvMsg return an *Error: the stream itself is
+// still usable, so handlers may report the error and keep receiving.
+func (r *Registry) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingStream{ServerStream: ss, registry: r})
+	}
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+	registry *Registry
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if msg, ok := m.(proto.Message); ok {
+		return s.registry.Validate(msg)
+	}
+	return nil
+}
+
+// field resolves a dotted path of proto field names, e.g. "product.id",
+// returning the message holding the last field and its descriptor.
+// ok is false if an intermediate message is unset.
+func field(m protoreflect.Message, path string) (protoreflect.Message, protoreflect.FieldDescriptor, bool) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			panic(fmt.Sprintf("validation: %v has no field %q", m.Descriptor().FullName(), path))
+		}
+		if i == len(names)-1 {
+			return m, fd, true
+		}
+		if !m.Has(fd) {
+			return nil, fd, false
+		}
+		m = m.Get(fd).Message()
+	}
+	return nil, nil, false
+}
+
+func violation(path, format string, args ...interface{}) []*Violation {
+	return []*Violation{{Field: path, Description: fmt.Sprintf(format, args...)}}
+}
+
+// Required reports the field when it has its zero value: an empty string or
+// list, an unset message, a zero number or an unspecified enum.
+func Required(path string) Rule {
+	return func(m protoreflect.Message) []*Violation {
+		parent, fd, ok := field(m, path)
+		if !ok || !parent.Has(fd) {
+			return violation(path, "is required")
+		}
+		return nil
+	}
+}
+
+// MinItems reports a repeated field with fewer than n elements.
+func MinItems(path string, n int) Rule {
+	return func(m protoreflect.Message) []*Violation {
+		parent, fd, ok := field(m, path)
+		if !ok || parent.Get(fd).List().Len() < n {
+			return violation(path, "must have at least %d element(s)", n)
+		}
+		return nil
+	}
+}
+
+// MaxLen reports a string field longer than n characters.
+func MaxLen(path string, n int) Rule {
+	return func(m protoreflect.Message) []*Violation {
+		parent, fd, ok := field(m, path)
+		if ok && len([]rune(parent.Get(fd).String())) > n {
+			return violation(path, "must be at most %d characters long", n)
+		}
+		return nil
+	}
+}
+
+// Money reports a google.type.Money field that is set but malformed,
+// e.g. with an invalid currency code.
+func Money(path string) Rule {
+	return func(m protoreflect.Message) []*Violation {
+		parent, fd, ok := field(m, path)
+		if !ok || !parent.Has(fd) {
+			return nil
+		}
+		if err := money.Validate(parent.Get(fd).Message().Interface().(*moneypb.Money)); err != nil {
+			return violation(path, "%v", strings.TrimPrefix(err.Error(), "money: "))
+		}
+		return nil
+	}
+}
+
+// Positive reports a numeric field that is not greater than zero.
+// Numeric fields include google.type.Money amounts; an unset amount is left
+// to Required.
+func Positive(path string) Rule {
+	return compare(path, "must be greater than 0", func(v float64) bool { return v > 0 })
+}
+
+// NonNegative reports a numeric field below zero.
+func NonNegative(path string) Rule {
+	return compare(path, "must not be negative", func(v float64) bool { return v >= 0 })
+}
+
+// Between reports a numeric field outside [min, max].
+func Between(path string, min, max float64) Rule {
+	return compare(path, fmt.Sprintf("must be between %v and %v", min, max), func(v float64) bool { return v >= min && v <= max })
+}
+
+func compare(path, description string, valid func(float64) bool) Rule {
+	return func(m protoreflect.Message) []*Violation {
+		parent, fd, ok := field(m, path)
+		if !ok {
+			return nil
+		}
+		var v float64
+		switch value := parent.Get(fd); fd.Kind() {
+		case protoreflect.MessageKind:
+			amount, isMoney := value.Message().Interface().(*moneypb.Money)
+			if !isMoney {
+				panic(fmt.Sprintf("validation: %v is not numeric", fd.FullName()))
+			}
+			if !parent.Has(fd) {
+				return nil
+			}
+			v = money.Float64(amount)
+		case protoreflect.FloatKind, protoreflect.DoubleKind:
+			v = value.Float()
+		case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind,
+			protoreflect.Sint64Kind, protoreflect.Sfixed32Kind, protoreflect.Sfixed64Kind:
+			v = float64(value.Int())
+		case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
+			v = float64(value.Uint())
+		default:
+			panic(fmt.Sprintf("validation: %v is not numeric", fd.FullName()))
+		}
+		if !valid(v) {
+			return violation(path, description)
+		}
+		return nil
+	}
+}
+
+// Each applies rules to every element of a repeated message field,
+// reporting violations with paths such as "line_items[2].quantity".
+func Each(path string, rules ...Rule) Rule {
+	return func(m protoreflect.Message) []*Violation {
+		parent, fd, ok := field(m, path)
+		if !ok {
+			return nil
+		}
+		var violations []*Violation
+		list := parent.Get(fd).List()
+		for i := 0; i < list.Len(); i++ {
+			for _, rule := range rules {
+				for _, v := range rule(list.Get(i).Message()) {
+					violations = append(violations, &Violation{
+						Field:       fmt.Sprintf("%v[%d].%v", path, i, v.Field),
+						Description: v.Description,
+					})
+				}
+			}
+		}
+		return violations
+	}
+}
+
+// When applies rules only to messages for which cond holds.
+func When(cond func(m protoreflect.Message) bool, rules ...Rule) Rule {
+	return func(m protoreflect.Message) []*Violation {
+		if !cond(m) {
+			return nil
+		}
+		var violations []*Violation
+		for _, rule := range rules {
+			violations = append(violations, rule(m)...)
+		}
+		return violations
+	}
+}